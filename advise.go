@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "advise",
+		summary: "compare limits, heap and processors settings against best practices",
+		run:     runAdvise,
+	})
+}
+
+// advisory is one concrete sizing suggestion for a data pool. pair,
+// when non-empty, is a key=value the settings subcommand can apply.
+type advisory struct {
+	pool string
+	text string
+	pair string
+}
+
+// The sizing rules encoded below: the CrateDB heap should sit between
+// 25% and 50% of the container memory limit (the rest feeds the OS
+// page cache and off-heap buffers) and stay under ~30.5G so the JVM
+// keeps compressed object pointers; the processors setting should
+// match the CPU limit, because inside a container the JVM otherwise
+// sizes its thread pools from the host's cores and oversubscribes the
+// cgroup quota.
+const (
+	heapRatioMin = 0.25
+	heapRatioMax = 0.50
+	heapOopsMax  = 30500 << 20 // ~30.5Gi, compressed-oops threshold
+)
+
+func runAdvise(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("advise", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	apply := fs.Bool("apply", false, "apply the applicable suggestions via the settings mechanism")
+	wait := fs.Duration("wait", 0, "with -apply, wait this long for the operator rollout to finish")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: %s advise [namespace/]cluster [-apply]", progName)
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+	sets, err := cratedb.StatefulSets(ctx, c, []cratedb.Cluster{cl})
+	if err != nil {
+		return err
+	}
+
+	advisories := adviseCluster(cl, sets[ns+"/"+name])
+	if len(advisories) == 0 {
+		cli.Infof("%s/%s: sizing matches best practices; nothing to advise", ns, name)
+		return nil
+	}
+	for _, a := range advisories {
+		fmt.Printf("[%s] %s\n", a.pool, a.text)
+	}
+	var pairs []string
+	for _, a := range advisories {
+		if a.pair != "" {
+			pairs = append(pairs, a.pair)
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	if !*apply {
+		fmt.Printf("\napply with: %s settings set %s/%s %s\n", progName, ns, name, strings.Join(pairs, " "))
+		return nil
+	}
+	return settingsSet(ctx, c, cl, pairs, *wait)
+}
+
+// adviseCluster derives advisories for every data pool from the CR and
+// the live StatefulSets (which carry the rendered CRATE_HEAP_SIZE).
+func adviseCluster(cl cratedb.Cluster, stss []map[string]any) []advisory {
+	var out []advisory
+	for _, pool := range cl.Spec.Nodes.Data {
+		mem := parseBytes(pool.Resources.Limit("memory"))
+		cpu := parseCores(pool.Resources.Limit("cpu"))
+
+		heap := int64(0)
+		heapSource := ""
+		if env := crateEnv(stss, pool.Name, "CRATE_HEAP_SIZE"); env != "" {
+			heap, heapSource = parseBytes(env), "CRATE_HEAP_SIZE="+env
+		} else if pool.Resources.HeapRatio > 0 && mem > 0 {
+			heap = int64(pool.Resources.HeapRatio * float64(mem))
+			heapSource = fmt.Sprintf("heapRatio=%g", pool.Resources.HeapRatio)
+		}
+
+		switch {
+		case mem == 0:
+			out = append(out, advisory{pool.Name,
+				"no memory limit set; heap and cache sizing are not deterministic without one", ""})
+		case heap == 0:
+			out = append(out, advisory{pool.Name,
+				fmt.Sprintf("no heap sizing found; set resources.heapRatio between %g and %g of the %s memory limit",
+					heapRatioMin, heapRatioMax, fmtBytes(mem)), ""})
+		default:
+			ratio := float64(heap) / float64(mem)
+			if ratio < heapRatioMin {
+				out = append(out, advisory{pool.Name,
+					fmt.Sprintf("%s is %.0f%% of the %s memory limit; raise it toward %.0f%% so queries stop spilling",
+						heapSource, ratio*100, fmtBytes(mem), heapRatioMin*100), ""})
+			}
+			if ratio > heapRatioMax {
+				out = append(out, advisory{pool.Name,
+					fmt.Sprintf("%s is %.0f%% of the %s memory limit; keep it at or below %.0f%% to leave room for the page cache",
+						heapSource, ratio*100, fmtBytes(mem), heapRatioMax*100), ""})
+			}
+			if heap > heapOopsMax {
+				out = append(out, advisory{pool.Name,
+					fmt.Sprintf("%s exceeds the ~30.5G compressed-oops threshold; cap the heap at 30500m or go well past 32G deliberately",
+						heapSource), ""})
+			}
+		}
+
+		if cpu == 0 {
+			out = append(out, advisory{pool.Name,
+				"no CPU limit set; the JVM sizes thread pools from the host's cores, not the pod's share", ""})
+			continue
+		}
+		want := int(math.Ceil(cpu))
+		current, ok := cl.Spec.Cluster.Settings["processors"]
+		if !ok || fmt.Sprint(current) != strconv.Itoa(want) {
+			have := "unset"
+			if ok {
+				have = fmt.Sprint(current)
+			}
+			out = append(out, advisory{pool.Name,
+				fmt.Sprintf("processors is %s but the CPU limit is %g; set it to %d so thread pools match the cgroup quota",
+					have, cpu, want),
+				fmt.Sprintf("cluster.settings.processors=%d", want)})
+		}
+	}
+	return out
+}
+
+// crateEnv reads an env value from the crate container of the pool's
+// StatefulSet, named crate-data-<pool>-<cluster> by the operator.
+func crateEnv(stss []map[string]any, pool, name string) string {
+	for _, sts := range stss {
+		if !strings.HasPrefix(unstructured.NestedString(sts, "metadata", "name"), "crate-data-"+pool+"-") {
+			continue
+		}
+		for _, ctr := range unstructured.NestedSlice(sts, "spec", "template", "spec", "containers") {
+			m, ok := ctr.(map[string]any)
+			if !ok || unstructured.NestedString(m, "name") != "crate" {
+				continue
+			}
+			for _, env := range unstructured.NestedSlice(m, "env") {
+				if em, ok := env.(map[string]any); ok && unstructured.NestedString(em, "name") == name {
+					return unstructured.NestedString(em, "value")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// parseCores parses a CPU quantity ("3500m", "4") into cores.
+func parseCores(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	if v, found := strings.CutSuffix(s, "m"); found {
+		n, _ := strconv.ParseFloat(v, 64)
+		return n / 1000
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// parseBytes parses Kubernetes ("4Gi") and JVM ("16g", "16G") size
+// spellings into bytes. JVM sizes are binary in either letter case;
+// treating a bare Kubernetes "M"/"G" (decimal) the same way is a <5%
+// error, well inside the advisory bands.
+func parseBytes(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	suffixes := []struct {
+		suffix string
+		factor float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1 << 10}, {"m", 1 << 20}, {"g", 1 << 30}, {"t", 1 << 40},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	}
+	for _, m := range suffixes {
+		if v, found := strings.CutSuffix(s, m.suffix); found {
+			f, _ := strconv.ParseFloat(v, 64)
+			return int64(f * m.factor)
+		}
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return int64(f)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+// alertPauser turns Alertmanager webhook posts into pause/resume
+// commands on a campaign's control socket: while any matching alert is
+// firing the campaign stays paused, and it resumes once the last one
+// resolves. Matching is by alert labels, so teams decide what counts
+// as "stop touching the fleet" (severity=critical by default).
+type alertPauser struct {
+	socket   string
+	matchers map[string]string
+
+	mu     sync.Mutex
+	firing map[string]bool
+	paused bool
+}
+
+func newAlertPauser(socket string, matchers map[string]string) *alertPauser {
+	return &alertPauser{socket: socket, matchers: matchers, firing: map[string]bool{}}
+}
+
+// parseMatchers turns "severity=critical,team=db" into a label map.
+func parseMatchers(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || k == "" {
+			return nil, fmt.Errorf("invalid alert matcher %q (want label=value)", pair)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// handle accepts one Alertmanager webhook POST.
+func (ap *alertPauser) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	var payload struct {
+		Alerts []struct {
+			Status      string            `json:"status"`
+			Fingerprint string            `json:"fingerprint"`
+			Labels      map[string]string `json:"labels"`
+		} `json:"alerts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	for _, a := range payload.Alerts {
+		if !ap.matches(a.Labels) {
+			continue
+		}
+		key := a.Fingerprint
+		if key == "" {
+			key = labelKey(a.Labels)
+		}
+		if a.Status == "firing" {
+			ap.firing[key] = true
+		} else {
+			delete(ap.firing, key)
+		}
+	}
+	switch {
+	case len(ap.firing) > 0 && !ap.paused:
+		cli.Warnf("%d matching alert(s) firing; pausing campaign", len(ap.firing))
+		if err := ap.send("pause"); err != nil {
+			cli.Warnf("%v", err)
+			break
+		}
+		ap.paused = true
+	case len(ap.firing) == 0 && ap.paused:
+		cli.Infof("matching alerts resolved; resuming campaign")
+		if err := ap.send("resume"); err != nil {
+			cli.Warnf("%v", err)
+			break
+		}
+		ap.paused = false
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ap *alertPauser) matches(labels map[string]string) bool {
+	for k, v := range ap.matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// send writes one command to the campaign control socket and logs the
+// reply. A missing socket is not fatal: the campaign may simply have
+// finished.
+func (ap *alertPauser) send(cmd string) error {
+	conn, err := net.Dial("unix", ap.socket)
+	if err != nil {
+		return fmt.Errorf("campaign control socket: %w", err)
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return err
+	}
+	sc := bufio.NewScanner(conn)
+	if sc.Scan() {
+		cli.Debugf("campaign control: %s", sc.Text())
+	}
+	return sc.Err()
+}
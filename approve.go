@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+func init() {
+	register(command{
+		name:    "approve",
+		summary: "record a second-person approval for a campaign started with -second-approval",
+		run:     runApprove,
+	})
+}
+
+// runApprove is the second half of two-person mode: an operator other
+// than the one running `crt restart -second-approval` signs off on the
+// campaign ID printed by that command. The approval is written to a
+// ConfigMap in the campaign's namespace, so both operators must point
+// at the same context and namespace.
+func runApprove(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt approve <campaign-id>")
+	}
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	id := fs.Arg(0)
+	approver := cli.Identity(c)
+	if err := campaign.Approve(ctx, c, id, approver); err != nil {
+		return err
+	}
+	cli.Infof("campaign %s approved by %s", id, approver)
+	return nil
+}
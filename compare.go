@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "compare",
+		summary: "compare two clusters' spec, possibly across contexts: [ctx:]ns/name [ctx:]ns/name",
+		run:     runCompare,
+	})
+}
+
+func runCompare(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: crt compare [context:]namespace/name [context:]namespace/name")
+	}
+
+	a, err := fetchCompareSide(ctx, &g, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := fetchCompareSide(ctx, &g, fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	rows := compareRows(a.cluster, b.cluster)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\t%s\t%s\tSTATE\n", a.label, b.label)
+	diffs := 0
+	for _, r := range rows {
+		state := "match"
+		if r.spec != r.live {
+			state = "DIFFERS"
+			diffs++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.field, r.spec, r.live, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if diffs > 0 {
+		return fmt.Errorf("%d field(s) differ between %s and %s", diffs, a.label, b.label)
+	}
+	cli.Infof("%s and %s match", a.label, b.label)
+	return nil
+}
+
+type compareSide struct {
+	label   string
+	cluster cratedb.Cluster
+}
+
+// fetchCompareSide resolves "[context:]namespace/name" against the
+// right cluster — a context prefix gets its own client so staging and
+// production can sit in different kubeconfig contexts.
+func fetchCompareSide(ctx context.Context, g *cli.GlobalFlags, arg string) (compareSide, error) {
+	kctx := ""
+	rest := arg
+	if i := strings.Index(arg, ":"); i >= 0 {
+		kctx, rest = arg[:i], arg[i+1:]
+	}
+	ns, name, found := strings.Cut(rest, "/")
+	if !found {
+		if g.Namespace == "" {
+			return compareSide{}, fmt.Errorf("target %q has no namespace and -namespace is not set", arg)
+		}
+		ns, name = g.Namespace, rest
+	}
+
+	var c *kube.Client
+	var err error
+	if kctx == "" {
+		c, err = g.Client()
+	} else {
+		var cfg *kube.Config
+		if cfg, err = kube.LoadConfig(kctx, g.Kubeconfig); err == nil {
+			c, err = kube.NewClient(cfg)
+		}
+	}
+	if err != nil {
+		return compareSide{}, err
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return compareSide{}, err
+	}
+	return compareSide{label: arg, cluster: cl}, nil
+}
+
+// compareRows diffs the fields that must match between environments
+// before a change is signed off: version, node counts, resources, and
+// explicit cluster settings.
+func compareRows(a, b cratedb.Cluster) []diffRow {
+	rows := []diffRow{
+		{"cluster.version", a.Version(), b.Version()},
+		{"nodes.data", fmt.Sprint(a.DataNodes()), fmt.Sprint(b.DataNodes())},
+	}
+
+	aPools := poolsByName(a)
+	bPools := poolsByName(b)
+	for _, name := range sortedPoolNames(aPools, bPools) {
+		prefix := "nodes.data." + name
+		ap, bp := aPools[name], bPools[name]
+		rows = append(rows,
+			poolRow(prefix+".replicas", ap, bp, func(p cratedb.DataPool) any { return p.Replicas }),
+			poolRow(prefix+".cpu", ap, bp, func(p cratedb.DataPool) any { return p.Resources.Limit("cpu") }),
+			poolRow(prefix+".memory", ap, bp, func(p cratedb.DataPool) any { return p.Resources.Limit("memory") }),
+			poolRow(prefix+".heap", ap, bp, func(p cratedb.DataPool) any { return p.Resources.HeapRatio }),
+			poolRow(prefix+".disk", ap, bp, func(p cratedb.DataPool) any { return p.Resources.Disk.Size }),
+		)
+	}
+
+	aSettings := map[string]any{}
+	bSettings := map[string]any{}
+	flatten("", a.Spec.Cluster.Settings, aSettings)
+	flatten("", b.Spec.Cluster.Settings, bSettings)
+	keys := map[string]bool{}
+	for k := range aSettings {
+		keys[k] = true
+	}
+	for k := range bSettings {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		rows = append(rows, diffRow{"settings." + k, settingString(aSettings, k), settingString(bSettings, k)})
+	}
+	return rows
+}
+
+func poolsByName(cl cratedb.Cluster) map[string]*cratedb.DataPool {
+	pools := map[string]*cratedb.DataPool{}
+	for i := range cl.Spec.Nodes.Data {
+		pool := &cl.Spec.Nodes.Data[i]
+		pools[pool.Name] = pool
+	}
+	return pools
+}
+
+func sortedPoolNames(sides ...map[string]*cratedb.DataPool) []string {
+	set := map[string]bool{}
+	for _, side := range sides {
+		for name := range side {
+			set[name] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func poolRow(field string, a, b *cratedb.DataPool, get func(cratedb.DataPool) any) diffRow {
+	return diffRow{field, poolField(a, get), poolField(b, get)}
+}
+
+func poolField(pool *cratedb.DataPool, get func(cratedb.DataPool) any) string {
+	if pool == nil {
+		return "-"
+	}
+	return fmt.Sprint(get(*pool))
+}
+
+func settingString(settings map[string]any, key string) string {
+	v, ok := settings[key]
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+var startTime = time.Now()
+
+// registerDebugHandlers wires pprof and a small runtime-stats endpoint
+// into mux. Only called when serve is started with -pprof, so the
+// profiling surface is opt-in.
+func registerDebugHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"goroutines":     runtime.NumGoroutine(),
+			"heap_alloc":     m.HeapAlloc,
+			"heap_objects":   m.HeapObjects,
+			"gc_cycles":      m.NumGC,
+			"uptime_seconds": int64(time.Since(startTime).Seconds()),
+			"go_version":     runtime.Version(),
+		})
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+func init() {
+	register(command{
+		name:    "deploy",
+		summary: "generate in-cluster manifests: generate -mode=cron|controller",
+		run:     runDeploy,
+	})
+}
+
+func runDeploy(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	mode := fs.String("mode", "cron", "cron (CronJob running restarts) or controller (serve Deployment)")
+	namespace := fs.String("namespace", "default", "namespace for the generated workload")
+	image := fs.String("image", "ghcr.io/walbeh/crt:latest", "container image")
+	schedule := fs.String("schedule", "0 3 * * 0", "CronJob schedule (cron mode)")
+	extraArgs := fs.String("args", "", "extra arguments appended to the container command, space-separated")
+
+	if len(args) < 1 || args[0] != "generate" {
+		return fmt.Errorf("usage: crt deploy generate -mode=cron|controller [flags]")
+	}
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	var rbacMode string
+	switch *mode {
+	case "cron":
+		rbacMode = "restart"
+	case "controller":
+		rbacMode = "controller"
+	default:
+		return fmt.Errorf("unknown mode %q (want cron or controller)", *mode)
+	}
+	rules, err := rbacRules(rbacMode)
+	if err != nil {
+		return err
+	}
+	fmt.Print(renderRBAC(rbacMode, *namespace, "crt", rules))
+	fmt.Print("---\n")
+	switch *mode {
+	case "cron":
+		fmt.Print(renderCronJob(*namespace, *image, *schedule, splitArgs(*extraArgs)))
+	case "controller":
+		fmt.Print(renderControllerDeployment(*namespace, *image, splitArgs(*extraArgs)))
+	}
+	return nil
+}
+
+func splitArgs(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+func renderArgs(b *strings.Builder, indent string, command []string) {
+	for _, a := range command {
+		fmt.Fprintf(b, "%s- %q\n", indent, a)
+	}
+}
+
+func renderCronJob(namespace, image, schedule string, extra []string) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: batch/v1\nkind: CronJob\nmetadata:\n")
+	b.WriteString("  name: crt-restart\n  namespace: " + namespace + "\n")
+	b.WriteString("spec:\n  schedule: \"" + schedule + "\"\n  concurrencyPolicy: Forbid\n")
+	b.WriteString("  jobTemplate:\n    spec:\n      backoffLimit: 0\n      template:\n        spec:\n")
+	b.WriteString("          serviceAccountName: crt\n          restartPolicy: Never\n")
+	b.WriteString("          containers:\n          - name: crt\n            image: " + image + "\n")
+	b.WriteString("            args:\n")
+	args := append([]string{"restart", "-all-namespaces"}, extra...)
+	renderArgs(&b, "            ", args)
+	return b.String()
+}
+
+func renderControllerDeployment(namespace, image string, extra []string) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n")
+	b.WriteString("  name: crt-controller\n  namespace: " + namespace + "\n")
+	b.WriteString("spec:\n  replicas: 2\n  selector:\n    matchLabels:\n      app: crt-controller\n")
+	b.WriteString("  template:\n    metadata:\n      labels:\n        app: crt-controller\n")
+	b.WriteString("    spec:\n      serviceAccountName: crt\n")
+	b.WriteString("      containers:\n      - name: crt\n        image: " + image + "\n")
+	b.WriteString("        args:\n")
+	args := append([]string{"serve", "-leader-elect"}, extra...)
+	renderArgs(&b, "        ", args)
+	b.WriteString("        ports:\n        - containerPort: 8080\n")
+	b.WriteString("        readinessProbe:\n          httpGet:\n            path: /healthz\n            port: 8080\n")
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "describe",
+		summary: "show a readable summary of one cluster's spec and status",
+		run:     runDescribe,
+	})
+}
+
+func runDescribe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt describe [namespace/]name")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+	row := func(k string, v any) { fmt.Fprintf(w, "%s:\t%v\n", k, v) }
+
+	row("Name", cl.Namespace+"/"+cl.Name)
+	row("Version", cl.Version())
+	row("Health", cl.Health())
+	if cl.Spec.Cluster.SSL != nil {
+		row("SSL", "enabled")
+	} else {
+		row("SSL", "disabled")
+	}
+	if cl.Spec.Cluster.Name != "" {
+		row("Cluster name", cl.Spec.Cluster.Name)
+	}
+
+	fmt.Fprintln(w, "\nNode pools:")
+	for _, pool := range cl.Spec.Nodes.Data {
+		fmt.Fprintf(w, "  %s:\treplicas=%d cpu=%v memory=%v disk=%v\n",
+			pool.Name,
+			pool.Replicas,
+			orDash(pool.Resources.Limit("cpu"), ""),
+			orDash(pool.Resources.Limit("memory"), ""),
+			orDash(pool.Resources.Disk.Size, ""))
+	}
+	if cl.Spec.Nodes.Master != nil {
+		fmt.Fprintf(w, "  master:\treplicas=%d\n", cl.Spec.Nodes.Master.Replicas)
+	}
+
+	if len(cl.Status.Conditions) > 0 {
+		fmt.Fprintln(w, "\nConditions:")
+		for _, cond := range cl.Status.Conditions {
+			fmt.Fprintf(w, "  %s:\t%s %s\n", cond.Type, cond.Status, cond.Message)
+		}
+	}
+
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "\nPods:")
+	for _, pod := range pods {
+		ready := "NotReady"
+		if cratedb.PodReady(pod) {
+			ready = "Ready"
+		}
+		line := fmt.Sprintf("  %s:\t%s %s node=%s",
+			unstructured.NestedString(pod, "metadata", "name"),
+			unstructured.NestedString(pod, "status", "phase"),
+			ready,
+			unstructured.NestedString(pod, "spec", "nodeName"))
+		if pc := unstructured.NestedString(pod, "spec", "priorityClassName"); pc != "" {
+			line += " priority=" + pc
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+func orDash(a, b string) string {
+	if a != "" {
+		return a
+	}
+	if b != "" {
+		return b
+	}
+	return "-"
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "diff",
+		summary: "compare CR spec against the live StatefulSet and pods",
+		run:     runDiff,
+	})
+}
+
+// diffRow is one compared field.
+type diffRow struct {
+	field string
+	spec  string
+	live  string
+}
+
+func (d diffRow) inSync() bool { return d.spec == d.live }
+
+func runDiff(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt diff [namespace/]name")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"labelSelector": []string{cl.PodSelector()}}
+	stss, err := c.List(ctx, kube.ResourcePath("apps/v1", ns, "statefulsets", ""), q)
+	if err != nil {
+		return fmt.Errorf("listing statefulsets: %w", err)
+	}
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+
+	rows := diffRows(cl, stss, pods)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tSPEC\tLIVE\tSTATE")
+	drift := 0
+	for _, r := range rows {
+		state := "in-sync"
+		if !r.inSync() {
+			state = "DRIFT"
+			drift++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.field, r.spec, r.live, state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if drift > 0 {
+		return fmt.Errorf("%d field(s) not reconciled yet", drift)
+	}
+	cli.Infof("%s/%s: spec and live state match", ns, name)
+	return nil
+}
+
+func diffRows(cl cratedb.Cluster, stss, pods []map[string]any) []diffRow {
+	var rows []diffRow
+
+	// Version: spec against the crate image tag of every pod.
+	specVersion := cl.Version()
+	liveVersions := map[string]bool{}
+	for _, pod := range pods {
+		for _, cv := range unstructured.NestedSlice(pod, "spec", "containers") {
+			container, ok := cv.(map[string]any)
+			if !ok || unstructured.NestedString(container, "name") != "crate" {
+				continue
+			}
+			liveVersions[imageTag(unstructured.NestedString(container, "image"))] = true
+		}
+	}
+	rows = append(rows, diffRow{"cluster.version", specVersion, joinKeys(liveVersions)})
+
+	// Replicas: spec data nodes against StatefulSet ready replicas.
+	var stsReplicas, stsReady int64
+	for _, sts := range stss {
+		stsReplicas += unstructured.NestedInt64(sts, "spec", "replicas")
+		stsReady += unstructured.NestedInt64(sts, "status", "readyReplicas")
+	}
+	rows = append(rows, diffRow{"nodes.replicas", fmt.Sprint(cl.DataNodes()), fmt.Sprint(stsReplicas)})
+	rows = append(rows, diffRow{"nodes.ready", fmt.Sprint(cl.DataNodes()), fmt.Sprint(stsReady)})
+
+	// Resources and disk: first data pool against the StatefulSet
+	// template (the operator renders pools into separate sets; compare
+	// pairwise by index where possible).
+	for i, pool := range cl.Spec.Nodes.Data {
+		if i >= len(stss) {
+			continue
+		}
+		sts := stss[i]
+		prefix := "nodes.data." + pool.Name
+
+		var liveCPU, liveMem string
+		for _, cv := range unstructured.NestedSlice(sts, "spec", "template", "spec", "containers") {
+			container, ok := cv.(map[string]any)
+			if !ok || unstructured.NestedString(container, "name") != "crate" {
+				continue
+			}
+			liveCPU = unstructured.NestedString(container, "resources", "limits", "cpu")
+			liveMem = unstructured.NestedString(container, "resources", "limits", "memory")
+		}
+		if specCPU := limitOnly(pool, "cpu"); specCPU != "" {
+			rows = append(rows, diffRow{prefix + ".cpu", specCPU, liveCPU})
+		}
+		if specMem := limitOnly(pool, "memory"); specMem != "" {
+			rows = append(rows, diffRow{prefix + ".memory", specMem, liveMem})
+		}
+		if specDisk := pool.Resources.Disk.Size; specDisk != "" {
+			var liveDisk string
+			for _, tv := range unstructured.NestedSlice(sts, "spec", "volumeClaimTemplates") {
+				tmpl, ok := tv.(map[string]any)
+				if !ok {
+					continue
+				}
+				liveDisk = unstructured.NestedString(tmpl, "spec", "resources", "requests", "storage")
+			}
+			rows = append(rows, diffRow{prefix + ".disk", specDisk, liveDisk})
+		}
+	}
+	return rows
+}
+
+// limitOnly returns the pool's resource limit (not the request) as a
+// string, matching the limits the operator puts on the container.
+func limitOnly(pool cratedb.DataPool, name string) string {
+	if v, ok := pool.Resources.Limits[name]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// imageTag extracts the tag of a container image reference.
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return "latest"
+}
+
+func joinKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return "-"
+	}
+	return strings.Join(keys, ",")
+}
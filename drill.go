@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "drill",
+		summary: "resilience drill: restart random pods of a cluster on a cadence and verify recovery",
+		run:     runDrill,
+	})
+}
+
+// runDrill deliberately restarts random pods of one (non-production)
+// cluster at a fixed cadence and verifies recovery against the same
+// gates a campaign uses, so failover behaviour is exercised before an
+// incident does it for you.
+func runDrill(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("drill", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "max wait for a pod to return Ready")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "max wait for the cluster to report GREEN")
+	fs.StringVar(&opts.MinHealth, "min-health", opts.MinHealth, "health required before and after each drill")
+	count := fs.Int("count", 5, "number of pod restarts to perform (0 = until interrupted)")
+	interval := fs.Duration("interval", 15*time.Minute, "pause between drills")
+	slo := fs.Duration("recovery-slo", 0, "fail when a pod takes longer than this to recover (0 = report only)")
+	yes := fs.Bool("yes", false, "skip the confirmation prompt")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if !cratedb.KnownHealth(opts.MinHealth) {
+		return fmt.Errorf("invalid -min-health %q", opts.MinHealth)
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt drill [namespace/]cluster")
+	}
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	t := campaign.Target{Namespace: ns, Name: name}
+	if err := checkProtected(ctx, c, []campaign.Target{t}, false, false); err != nil {
+		return err
+	}
+	if !*yes && !cli.Confirm(fmt.Sprintf("drill will repeatedly delete pods of %s; this is for non-production clusters", t)) {
+		return fmt.Errorf("aborted")
+	}
+
+	cp := campaign.New([]campaign.Target{t}, opts)
+	cp.Operator = cli.Identity(c)
+	for i := 0; *count == 0 || i < *count; i++ {
+		if i > 0 {
+			cli.Infof("%s: next drill in %s", t, *interval)
+			if err := cli.Sleep(ctx, *interval); err != nil {
+				return err
+			}
+		}
+		cl, err := cratedb.Get(ctx, c, ns, name)
+		if err != nil {
+			return err
+		}
+		if h := cl.Health(); !cratedb.HealthAtLeast(h, opts.MinHealth) {
+			return fmt.Errorf("drill %d: health is %s, below %s; not making it worse", i+1, h, opts.MinHealth)
+		}
+		pods, err := cratedb.Pods(ctx, c, cl)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("no crate pods found")
+		}
+		pod := unstructured.NestedString(pods[rand.Intn(len(pods))], "metadata", "name")
+		cli.Infof("%s: drill %d: restarting pod %s", t, i+1, pod)
+		started := time.Now()
+		if err := cp.RestartPod(ctx, c, t, pod); err != nil {
+			return fmt.Errorf("drill %d: pod %s: %w", i+1, pod, err)
+		}
+		took := time.Since(started).Round(time.Second)
+		if *slo > 0 && took > *slo {
+			return fmt.Errorf("drill %d: pod %s recovered in %s, over the %s SLO", i+1, pod, took, *slo)
+		}
+		cli.Infof("%s: drill %d: pod %s recovered in %s", t, i+1, pod, took)
+	}
+	return nil
+}
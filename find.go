@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/query"
+)
+
+func init() {
+	register(command{
+		name:    "find",
+		summary: "query the fleet with a field expression: find -where EXPR",
+		run:     runFind,
+	})
+}
+
+func runFind(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("find", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	where := fs.String("where", "",
+		"filter expression over CR fields, e.g. 'spec.cluster.version < 5.6 && health != GREEN'")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if *where == "" {
+		return fmt.Errorf("usage: %s find -where 'EXPR'", progName)
+	}
+	expr, err := query.Parse(*where)
+	if err != nil {
+		return fmt.Errorf("parsing -where: %w", err)
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cratedb.SetHealthMapping(cfg.HealthPath, cfg.GreenValues)
+	clusters, err := cratedb.List(ctx, c, g.ResolveNamespace(c))
+	if err != nil {
+		return err
+	}
+	for _, cl := range clusters {
+		if !expr.Match(cl.Obj) {
+			continue
+		}
+		// Plain namespace/name lines, consumable by restart -from-file.
+		fmt.Printf("%s/%s\n", cl.Namespace, cl.Name)
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+module github.com/WalBeh/go-tool-p1
+
+go 1.21
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+func init() {
+	register(command{
+		name:    "grafana",
+		summary: "generate Grafana assets: grafana generate-dashboard",
+		run:     runGrafana,
+	})
+}
+
+func runGrafana(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("grafana", flag.ExitOnError)
+	datasource := fs.String("datasource", "Prometheus", "datasource name the panels reference")
+	out := fs.String("out", "", "write the dashboard JSON to this file instead of stdout")
+	if len(args) < 1 || args[0] != "generate-dashboard" {
+		return fmt.Errorf("usage: %s grafana generate-dashboard [-datasource NAME] [-out FILE]", progName)
+	}
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(fleetDashboard(*datasource), "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if err := os.WriteFile(*out, b, 0o644); err != nil {
+		return err
+	}
+	cli.Infof("dashboard written to %s", *out)
+	return nil
+}
+
+// fleetDashboard builds a dashboard over the metrics this tool
+// exposes: the serve daemon's /metrics health gauges and the
+// pushgateway campaign metrics. The JSON targets the current Grafana
+// schema but sticks to plain stat/timeseries panels so older
+// installations import it too.
+func fleetDashboard(datasource string) map[string]any {
+	return map[string]any{
+		"title":         "CrateDB Fleet (crt)",
+		"uid":           "crt-fleet",
+		"schemaVersion": 39,
+		"refresh":       "1m",
+		"time":          map[string]any{"from": "now-24h", "to": "now"},
+		"panels": []map[string]any{
+			grafanaPanel(1, "stat", "Clusters not GREEN", datasource, 0, 0, 6, 4,
+				"count(crt_cluster_health < 3) or vector(0)", ""),
+			grafanaPanel(2, "stat", "Fleet size", datasource, 6, 0, 6, 4,
+				"crt_fleet_clusters", ""),
+			grafanaPanel(3, "stat", "Pods restarted (last campaign)", datasource, 12, 0, 6, 4,
+				"crt_pods_restarted_total", ""),
+			grafanaPanel(4, "stat", "Clusters failed (last campaign)", datasource, 18, 0, 6, 4,
+				"crt_clusters_failed_total", ""),
+			grafanaPanel(5, "timeseries", "Cluster health (0=UNREACHABLE, 3=GREEN)", datasource, 0, 4, 12, 8,
+				"crt_cluster_health", "{{namespace}}/{{name}}"),
+			grafanaPanel(6, "timeseries", "Pod time-to-GREEN", datasource, 12, 4, 12, 8,
+				"crt_pod_restart_seconds", "{{name}} {{pod}}"),
+			grafanaPanel(7, "timeseries", "Cluster restart duration", datasource, 0, 12, 12, 8,
+				"crt_cluster_restart_seconds", "{{namespace}}/{{name}}"),
+			grafanaPanel(8, "timeseries", "Campaign duration", datasource, 12, 12, 12, 8,
+				"crt_campaign_duration_seconds", "campaign"),
+		},
+	}
+}
+
+func grafanaPanel(id int, kind, title, datasource string, x, y, w, h int, expr, legend string) map[string]any {
+	target := map[string]any{"expr": expr, "refId": "A"}
+	if legend != "" {
+		target["legendFormat"] = legend
+	}
+	return map[string]any{
+		"id":         id,
+		"type":       kind,
+		"title":      title,
+		"datasource": datasource,
+		"gridPos":    map[string]any{"x": x, "y": y, "w": w, "h": h},
+		"targets":    []map[string]any{target},
+	}
+}
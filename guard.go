@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// checkProtected enforces the protected-cluster guard: clusters listed
+// under "protected" in the config file or carrying the tool's
+// protected=true annotation are refused outright, and even -break-glass
+// only proceeds after the operator types each cluster's full name. The
+// guard exists so a mistyped glob on a bastion host cannot roll the
+// clusters that must never go down casually. Unmanaged StatefulSets
+// have no CR to carry the annotation, so only the config list applies
+// to them.
+func checkProtected(ctx context.Context, c *kube.Client, targets []campaign.Target, breakGlass, unmanaged bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	denied := map[string]bool{}
+	for _, name := range cfg.Protected {
+		denied[name] = true
+	}
+	for _, t := range targets {
+		protected := denied[t.String()]
+		if !protected && !unmanaged {
+			cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+			if err != nil {
+				return err
+			}
+			protected = cl.Annotation("protected") == "true"
+		}
+		if !protected {
+			continue
+		}
+		if !breakGlass {
+			return fmt.Errorf("%s is protected; restarting it requires -break-glass", t)
+		}
+		cli.Warnf("%s is protected; break-glass override requested", t)
+		if !cli.ConfirmTyped(fmt.Sprintf("restart protected cluster %s", t), t.String()) {
+			return fmt.Errorf("confirmation for protected cluster %s did not match", t)
+		}
+	}
+	return nil
+}
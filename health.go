@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/history"
+)
+
+func init() {
+	register(command{
+		name:    "health",
+		summary: "health SLO reporting from recorded transitions: history",
+		run:     runHealth,
+	})
+}
+
+func runHealth(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("health", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	file := fs.String("transitions-file", history.DefaultTransitionsPath(), "transitions file written by serve")
+	window := fs.String("window", "7d", "reporting window, e.g. 24h or 30d")
+
+	if len(args) < 1 || args[0] != "history" {
+		return fmt.Errorf("usage: crt health history [namespace/]cluster [-window=7d]")
+	}
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt health history [namespace/]cluster")
+	}
+	win, err := parseDays(*window)
+	if err != nil {
+		return err
+	}
+
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		if g.Namespace == "" {
+			return fmt.Errorf("target %q has no namespace and -namespace is not set", fs.Arg(0))
+		}
+		ns, name = g.Namespace, fs.Arg(0)
+	}
+
+	transitions, err := history.ReadTransitions(*file)
+	if err != nil {
+		return err
+	}
+	stats := history.Stats(transitions, ns, name, win, time.Now())
+	fmt.Printf("%s/%s over %s:\n", ns, name, *window)
+	fmt.Printf("  GREEN:        %.2f%%\n", stats.GreenPct)
+	fmt.Printf("  incidents:    %d\n", stats.Transitions)
+	fmt.Printf("  current:      %s\n", stats.Current)
+
+	shown := 0
+	for i := len(transitions) - 1; i >= 0 && shown < 10; i-- {
+		t := transitions[i]
+		if t.Namespace != ns || t.Cluster != name || time.Since(t.Time) > win {
+			continue
+		}
+		if shown == 0 {
+			fmt.Println("  recent transitions:")
+		}
+		fmt.Printf("    %s  %s -> %s\n", t.Time.Format("2006-01-02 15:04:05"), t.From, t.To)
+		shown++
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/history"
+)
+
+func init() {
+	register(command{
+		name:    "history",
+		summary: "show recorded time-to-GREEN trends per cluster",
+		run:     runHistory,
+	})
+}
+
+func runHistory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	file := fs.String("history-file", history.DefaultPath(), "history file to read")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	entries, err := history.Read(*file)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("no history recorded yet")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tCLUSTER\tRUNS\tAVG\tLAST\tTREND\tWHEN")
+	for _, t := range history.Trends(entries) {
+		trend := "-"
+		if t.Runs > 1 {
+			trend = fmt.Sprintf("%+.0f%%", t.DeltaPct)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			t.Namespace, t.Cluster, t.Runs,
+			fmtSecs(t.AvgSecs), fmtSecs(t.LastSecs), trend,
+			t.LastTime.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+func fmtSecs(s float64) string {
+	return (time.Duration(s) * time.Second).String()
+}
+
+// historyEntries converts campaign results to history records.
+func historyEntries(cp *campaign.Campaign) []history.Entry {
+	var entries []history.Entry
+	for _, res := range cp.Results {
+		if res.Status == "skipped" {
+			continue
+		}
+		for _, p := range res.Pods {
+			status := "succeeded"
+			if p.Error != "" {
+				status = "failed"
+			}
+			entries = append(entries, history.Entry{
+				Time:       p.Finished,
+				CampaignID: cp.ID,
+				Namespace:  res.Namespace,
+				Cluster:    res.Name,
+				Pod:        p.Pod,
+				Kind:       "pod",
+				Seconds:    p.Finished.Sub(p.Started).Seconds(),
+				Status:     status,
+			})
+		}
+		entries = append(entries, history.Entry{
+			Time:       res.Finished,
+			CampaignID: cp.ID,
+			Namespace:  res.Namespace,
+			Cluster:    res.Name,
+			Kind:       "cluster",
+			Seconds:    res.Finished.Sub(res.Started).Seconds(),
+			Status:     res.Status,
+		})
+	}
+	return entries
+}
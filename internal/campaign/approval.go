@@ -0,0 +1,184 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Approval modes for Options.ApproveEach. The http mode takes a listen
+// address after the equals sign, e.g. "http=:8090".
+const (
+	ApprovePrompt     = "prompt"
+	ApproveAnnotation = "annotation"
+	approveHTTPPrefix = "http="
+)
+
+// awaitApproval blocks until the restart of t has been signed off,
+// according to Options.ApproveEach: interactively on the terminal, by
+// flipping the approve annotation on the CR, or via an HTTP callback.
+func (cp *Campaign) awaitApproval(ctx context.Context, c *kube.Client, t Target, podCount int) error {
+	mode := cp.Options.ApproveEach
+	switch {
+	case mode == "":
+		return nil
+	case mode == ApprovePrompt:
+		cli.Infof("%s: plan: restart %d pod(s), highest ordinal first", t, podCount)
+		if !cli.Confirm(fmt.Sprintf("proceed with %s", t)) {
+			return fmt.Errorf("approval denied")
+		}
+		return nil
+	case mode == ApproveAnnotation:
+		return cp.awaitAnnotationApproval(ctx, c, t, podCount)
+	case strings.HasPrefix(mode, approveHTTPPrefix):
+		return cp.awaitHTTPApproval(ctx, t, strings.TrimPrefix(mode, approveHTTPPrefix), podCount)
+	default:
+		return fmt.Errorf("invalid approval mode %q (want prompt, annotation, or http=ADDR)", mode)
+	}
+}
+
+// awaitAnnotationApproval waits until someone sets the approve
+// annotation to "true" or this campaign's ID, then consumes it so a
+// later campaign needs a fresh sign-off.
+func (cp *Campaign) awaitAnnotationApproval(ctx context.Context, c *kube.Client, t Target, podCount int) error {
+	cli.Infof("%s: restart of %d pod(s) pending; approve with annotation %sapprove=%s (or \"true\")",
+		t, podCount, cratedb.AnnotationPrefix, cp.ID)
+	for {
+		cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+		if err != nil {
+			return err
+		}
+		if v := cl.Annotation("approve"); v == "true" || v == cp.ID {
+			path := kube.ResourcePath(cratedb.APIVersion, t.Namespace, cratedb.Plural, t.Name)
+			patch := map[string]any{"metadata": map[string]any{
+				"annotations": map[string]any{cratedb.AnnotationPrefix + "approve": nil},
+			}}
+			if err := c.Patch(ctx, path, patch); err != nil {
+				cli.Warnf("%s: clearing approve annotation: %v", t, err)
+			}
+			return nil
+		}
+		if err := cli.Sleep(ctx, cp.Options.Poll); err != nil {
+			return err
+		}
+	}
+}
+
+// approvalName returns the ConfigMap that carries the second-person
+// sign-off for a campaign.
+func approvalName(id string) string { return "crt-approval-" + id }
+
+// Approve records a sign-off for campaign id by approver, backing the
+// `crt approve` subcommand. The state lives in a ConfigMap so it works
+// across terminals and hosts and leaves an audit trail.
+func Approve(ctx context.Context, c *kube.Client, id, approver string) error {
+	name := approvalName(id)
+	obj := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": c.Namespace(),
+		},
+		"data": map[string]any{
+			"approved-by": approver,
+			"approved-at": time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	return c.Apply(ctx, kube.ResourcePath("v1", c.Namespace(), "configmaps", name), obj)
+}
+
+// AwaitSecondApproval blocks until an operator other than the one
+// running the campaign has signed off via `crt approve`, or the
+// validity window expires. Approvals older than the window are ignored
+// so a stale ConfigMap cannot authorize a later campaign.
+func (cp *Campaign) AwaitSecondApproval(ctx context.Context, c *kube.Client, window time.Duration) error {
+	cli.Infof("campaign %s: two-person mode: a second operator must run `crt approve %s` within %s",
+		cp.ID, cp.ID, window)
+	deadline := time.Now().Add(window)
+	path := kube.ResourcePath("v1", c.Namespace(), "configmaps", approvalName(cp.ID))
+	warned := ""
+	for {
+		var cm map[string]any
+		err := c.Get(ctx, path, &cm)
+		switch {
+		case kube.IsNotFound(err):
+		case err != nil:
+			return err
+		default:
+			by := unstructured.NestedString(cm, "data", "approved-by")
+			at, _ := time.Parse(time.RFC3339, unstructured.NestedString(cm, "data", "approved-at"))
+			switch {
+			case by == "":
+			case by == cp.Operator:
+				if warned != by {
+					cli.Warnf("campaign %s: ignoring approval by %s: the approver must not be the operator", cp.ID, by)
+					warned = by
+				}
+			case time.Since(at) > window:
+				if warned != by {
+					cli.Warnf("campaign %s: ignoring approval by %s from %s: outside the validity window", cp.ID, by, at.Format(time.RFC3339))
+					warned = by
+				}
+			default:
+				cli.Infof("campaign %s: approved by %s", cp.ID, by)
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("campaign %s: no second approval within %s", cp.ID, window)
+		}
+		if err := cli.Sleep(ctx, cp.Options.Poll); err != nil {
+			return err
+		}
+	}
+}
+
+// awaitHTTPApproval waits for POST /approve?cluster=namespace/name on
+// the campaign's approval listener, started on first use.
+func (cp *Campaign) awaitHTTPApproval(ctx context.Context, t Target, addr string, podCount int) error {
+	if cp.approvals == nil {
+		cp.approvals = make(chan string)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/approve", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "POST only", http.StatusMethodNotAllowed)
+				return
+			}
+			select {
+			case cp.approvals <- r.URL.Query().Get("cluster"):
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.Error(w, "no approval pending", http.StatusConflict)
+			}
+		})
+		srv := &http.Server{Addr: addr, Handler: mux}
+		go srv.ListenAndServe()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+		cli.Infof("approval endpoint: POST http://%s/approve?cluster=namespace/name", addr)
+	}
+	cli.Infof("%s: restart of %d pod(s) pending HTTP approval", t, podCount)
+	for {
+		select {
+		case cluster := <-cp.approvals:
+			if cluster == "" || cluster == t.String() {
+				return nil
+			}
+			cli.Warnf("approval for %q does not match pending cluster %s", cluster, t)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
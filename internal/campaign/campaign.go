@@ -0,0 +1,790 @@
+// Package campaign implements rolling restarts of CrateDB clusters.
+// A campaign covers one or more clusters; within a cluster, pods are
+// deleted one at a time and the campaign waits for the pod to become
+// Ready and the cluster to report GREEN before moving on.
+package campaign
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/prom"
+	"github.com/WalBeh/go-tool-p1/internal/query"
+	"github.com/WalBeh/go-tool-p1/internal/tracing"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Target names one cluster to restart.
+type Target struct {
+	Namespace string
+	Name      string
+}
+
+func (t Target) String() string { return t.Namespace + "/" + t.Name }
+
+// Options control campaign pacing and safety.
+type Options struct {
+	PodTimeout      time.Duration // max wait for one pod to return Ready
+	HealthTimeout   time.Duration // max wait for the cluster to go GREEN
+	Poll            time.Duration // poll interval for state checks
+	Force           bool          // restart even if the cluster is not GREEN
+	DryRun          bool          // log actions without deleting pods
+	OnTimeout       string        // health timeout escalation: fail, pause, skip, or force
+	ApproveEach     string        // per-cluster approval gate: prompt, annotation, http=ADDR, or ""
+	Parallel        int           // clusters restarted concurrently (1 = sequential)
+	FairShare       bool          // interleave targets round-robin across namespaces
+	MinHealth       string        // health considered good enough (default GREEN)
+	QuorumCheck     bool          // verify quorum and data copies via SQL before touching pods
+	AckDataLoss     bool          // proceed past zero-replica tables found by the preflight
+	Flush           bool          // flush tables and drain the translog before each pod
+	RecoveryRate    string        // temporary indices.recovery.max_bytes_per_sec during the restart ("" = leave alone)
+	SnapshotCheck   bool          // hold off while a snapshot or restore is running
+	SnapshotWait    time.Duration // how long the snapshot gate waits before skipping the cluster
+	BlockCheck      bool          // refuse clusters with write blocks or disabled allocation
+	PostReadySoak   time.Duration // extra observation time after a pod is Ready and health is back
+	LatencyFactor   float64       // abort when query latency grows beyond this factor after a pod (0 = off)
+	PromURL         string        // Prometheus base URL for PromGate
+	PromGate        prom.Gate     // SLO condition checked before each pod (zero Expr = off)
+	Explain         bool          // print the inputs behind every skip/proceed/wait decision
+	PodEstimate     time.Duration // historical per-pod time-to-GREEN, seeding the progress ETA (0 = none)
+	TenantLabels    []string      // CR label keys identifying the owning team, for results and metrics
+	EffortSort      bool          // order clusters by recovery effort, largest first (via SQL)
+	PodPause        time.Duration // minimum pause between pods of a cluster (0 = none)
+	AdaptivePace    float64       // additionally wait this fraction of the previous pod's recovery time (0 = off)
+	VerifyRetries   int           // retries of a pod's verification phase on transient failure (0 = none)
+	Pods            []string      // restrict to these pods: names, ordinals, or ranges like "0-2" (empty = all)
+	SkipPods        []string      // leave these pods alone, same selector syntax as Pods
+	VerifyRev       bool          // after the cluster, fail pods still on the old StatefulSet revision
+	NodeCount       bool          // after each pod, verify via SQL that it rejoined and sys.nodes is complete
+	SplitBrain      bool          // before the cluster, verify all nodes agree on the elected master
+	ProbeHTTP       bool          // after each pod turns Ready, require its HTTP admin port to answer
+	Unmanaged       bool          // targets are plain StatefulSets without a CR; gate on SQL/HTTP health only
+	CapacityCheck   bool          // before each delete, hold until a node could schedule the replacement
+	CapacityWait    time.Duration // how long the capacity gate holds (e.g. for an autoscaler node) before failing
+	PreemptionCheck bool          // surface pod priority; abort when recreating would preempt critical system pods
+	Reason          string        // operator-stated cause of the campaign, recorded in annotations, Events, reports and notifications
+	Policy          *query.Expr   // preflight policy over CR/StatefulSet/SQL fields (nil = none)
+
+	// TierOrder restarts the named data pools in this order (pools not
+	// listed follow in spec order); TierMinHealth overrides MinHealth
+	// for pods of specific pools.
+	TierOrder     []string
+	TierMinHealth map[string]string
+}
+
+// Escalation policies for OnTimeout.
+const (
+	TimeoutFail  = "fail"  // abort the campaign (default)
+	TimeoutPause = "pause" // ask a human whether to keep waiting
+	TimeoutSkip  = "skip"  // give up on this cluster, continue with the rest
+	TimeoutForce = "force" // ask a human, then continue without GREEN
+)
+
+// DefaultOptions are the pacing defaults used by the restart command.
+func DefaultOptions() Options {
+	return Options{
+		PodTimeout:    10 * time.Minute,
+		HealthTimeout: 30 * time.Minute,
+		Poll:          kube.WaitBackoff,
+		OnTimeout:     TimeoutFail,
+		SnapshotWait:  10 * time.Minute,
+		CapacityWait:  5 * time.Minute,
+		Parallel:      1,
+		MinHealth:     cratedb.HealthGreen,
+	}
+}
+
+// ErrHealthTimeout marks a cluster whose health did not recover within
+// HealthTimeout, so callers can tell timeouts apart from hard errors.
+var ErrHealthTimeout = errors.New("timed out waiting for cluster health")
+
+// PodResult records the restart of a single pod.
+type PodResult struct {
+	Pod      string    `json:"pod"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Attempts is recorded when the verification phase needed more
+	// than one try (see Options.VerifyRetries).
+	Attempts int    `json:"attempts,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ClusterResult records the restart of one cluster.
+type ClusterResult struct {
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Tenant    string      `json:"tenant,omitempty"` // owning team from the CR's tenant labels
+	Status    string      `json:"status"`           // succeeded, failed, skipped
+	Started   time.Time   `json:"started"`
+	Finished  time.Time   `json:"finished"`
+	Pods      []PodResult `json:"pods"`
+	Error     string      `json:"error,omitempty"`
+
+	// Settings audits cluster settings temporarily changed for this
+	// restart, so reports show what was touched and that it was undone.
+	Settings []string `json:"settings,omitempty"`
+}
+
+// Campaign is one restart run over a set of clusters.
+type Campaign struct {
+	ID       string `json:"id"`
+	Operator string `json:"operator,omitempty"` // acting identity, see cli.Identity
+
+	Targets []Target        `json:"targets"`
+	Options Options         `json:"-"`
+	Results []ClusterResult `json:"results"`
+
+	// Tracer, when non-nil, receives campaign/cluster/pod spans.
+	Tracer *tracing.Tracer `json:"-"`
+
+	// Control, when non-nil, is consulted between pods so an operator
+	// can pause, skip, or abort a running campaign.
+	Control *Control `json:"-"`
+
+	// Stream, when non-nil, receives one NDJSON event per campaign
+	// action for external orchestration.
+	Stream *EventStream `json:"-"`
+
+	span      *tracing.Span // campaign root span while running
+	approvals chan string   // HTTP approval callbacks, created on first use
+	prog      *progress     // in-place terminal progress, set by Run
+}
+
+// New builds a campaign with a fresh random ID.
+func New(targets []Target, opts Options) *Campaign {
+	return &Campaign{ID: newID(), Targets: targets, Options: opts}
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// Run restarts all targets, Options.Parallel clusters at a time (one
+// by default). The first failing cluster stops new clusters from
+// starting; targets never started are recorded as skipped.
+func (cp *Campaign) Run(ctx context.Context, c *kube.Client) error {
+	targets := cp.Targets
+	switch {
+	case cp.Options.EffortSort && !cp.Options.DryRun:
+		targets = effortOrder(ctx, c, targets)
+	case cp.Options.FairShare:
+		targets = fairOrder(targets)
+	}
+	workers := cp.Options.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	cli.Infof("campaign %s: %d cluster(s), %d at a time", cp.ID, len(targets), workers)
+	cli.Tag = cp.ID
+	defer func() { cli.Tag = "" }()
+	cp.span = cp.Tracer.Start(nil, "campaign",
+		tracing.String("campaign.id", cp.ID),
+		tracing.Int("campaign.clusters", len(targets)))
+	cp.prog = newProgress(len(targets), cp.Options.PodEstimate)
+	defer cli.ProgressDone()
+	cp.Stream.emit(StreamEvent{Type: "campaign-started", Campaign: cp.ID, Clusters: len(targets)})
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		failed  error
+		results = make([]ClusterResult, len(targets))
+		sem     = make(chan struct{}, workers)
+	)
+	for i, t := range targets {
+		sem <- struct{}{}
+		mu.Lock()
+		if failed == nil && cp.Control.Aborted() {
+			failed = fmt.Errorf("aborted by operator")
+		}
+		stop := failed != nil
+		mu.Unlock()
+		if stop {
+			results[i] = ClusterResult{Namespace: t.Namespace, Name: t.Name, Status: "skipped"}
+			<-sem
+			continue
+		}
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res := cp.restartCluster(ctx, c, t)
+			mu.Lock()
+			results[i] = res
+			if res.Status == "failed" && failed == nil {
+				failed = fmt.Errorf("cluster %s: %s", t, res.Error)
+			}
+			mu.Unlock()
+		}(i, t)
+	}
+	wg.Wait()
+	cp.Results = results
+	cp.span.End(failed)
+	done := StreamEvent{Type: "campaign-done", Campaign: cp.ID, Status: "succeeded"}
+	if failed != nil {
+		done.Status, done.Error = "failed", failed.Error()
+	}
+	cp.Stream.emit(done)
+	if err := cp.Tracer.Flush(ctx); err != nil {
+		cli.Warnf("%v", err)
+	}
+	return failed
+}
+
+// fairOrder interleaves targets round-robin across namespaces so no
+// single tenant's backlog monopolizes the workers.
+func fairOrder(targets []Target) []Target {
+	byNS := map[string][]Target{}
+	var order []string
+	for _, t := range targets {
+		if _, seen := byNS[t.Namespace]; !seen {
+			order = append(order, t.Namespace)
+		}
+		byNS[t.Namespace] = append(byNS[t.Namespace], t)
+	}
+	out := make([]Target, 0, len(targets))
+	for len(out) < len(targets) {
+		for _, ns := range order {
+			if len(byNS[ns]) > 0 {
+				out = append(out, byNS[ns][0])
+				byNS[ns] = byNS[ns][1:]
+			}
+		}
+	}
+	return out
+}
+
+// explainf prints one structured decision line when -explain is on:
+// the decision taken, the cluster it concerns, and every input that
+// went into it as key=value pairs, so "why did it do that?" can be
+// answered from the log instead of from the source.
+func (cp *Campaign) explainf(decision string, t Target, kv ...any) {
+	if !cp.Options.Explain {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "explain: decision=%s cluster=%s", decision, t)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", kv[i], kv[i+1])
+	}
+	cli.Infof("%s", b.String())
+}
+
+func (cp *Campaign) restartCluster(ctx context.Context, c *kube.Client, t Target) ClusterResult {
+	if cp.Options.Unmanaged {
+		return cp.restartUnmanaged(ctx, c, t)
+	}
+	res := ClusterResult{Namespace: t.Namespace, Name: t.Name, Started: time.Now()}
+	clusterSpan := cp.Tracer.Start(cp.span, "cluster",
+		tracing.String("cratedb.namespace", t.Namespace),
+		tracing.String("cratedb.name", t.Name))
+	defer func() {
+		cp.Stream.emit(StreamEvent{Type: "cluster-done", Campaign: cp.ID,
+			Cluster: t.String(), Status: res.Status, Error: res.Error})
+	}()
+	fail := func(err error) ClusterResult {
+		res.Status = "failed"
+		res.Error = err.Error()
+		res.Finished = time.Now()
+		clusterSpan.End(err)
+		cp.emitEvent(ctx, c, t, "RestartFailed", err.Error())
+		cp.Stream.emit(StreamEvent{Type: "error", Campaign: cp.ID, Cluster: t.String(), Error: err.Error()})
+		return res
+	}
+
+	cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+	if err != nil {
+		return fail(err)
+	}
+	opts := cp.optionsFor(t, cl)
+	res.Tenant = cl.Tenant(opts.TenantLabels)
+	if h := cl.Health(); !cratedb.HealthAtLeast(h, opts.MinHealth) && !opts.Force {
+		cp.explainf("refuse", t, "health", h, "min_health", opts.MinHealth, "force", opts.Force)
+		return fail(fmt.Errorf("health is %s, below %s (use -force to override)", h, opts.MinHealth))
+	}
+	cp.explainf("proceed", t,
+		"health", cl.Health(), "min_health", opts.MinHealth, "force", opts.Force,
+		"health_timeout", opts.HealthTimeout, "pod_timeout", opts.PodTimeout,
+		"min_health_annotation", cl.Annotation("min-health"))
+
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return fail(err)
+	}
+	if len(pods) == 0 {
+		return fail(fmt.Errorf("no crate pods found"))
+	}
+	if len(opts.Pods) > 0 {
+		pods = filterPods(pods, opts.Pods)
+		if len(pods) == 0 {
+			return fail(fmt.Errorf("no pods match -pods=%s", strings.Join(opts.Pods, ",")))
+		}
+	}
+	if len(opts.SkipPods) > 0 {
+		var skipped []string
+		pods, skipped = excludePods(pods, opts.SkipPods)
+		if len(skipped) > 0 {
+			cli.Infof("%s: leaving %s alone on request", t, strings.Join(skipped, ", "))
+		}
+		if len(pods) == 0 {
+			return fail(fmt.Errorf("-skip-pods excludes every pod of the cluster"))
+		}
+	}
+	cp.prog.startCluster(t, len(pods))
+	defer cp.prog.clusterDone(t)
+	cp.Stream.emit(StreamEvent{Type: "cluster-started", Campaign: cp.ID, Cluster: t.String()})
+	if opts.QuorumCheck && !opts.DryRun {
+		if err := quorumPreflight(ctx, c, cl, opts.AckDataLoss); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.SplitBrain && !opts.DryRun {
+		if err := masterConsensus(ctx, c, cl); err != nil {
+			return fail(fmt.Errorf("split-brain check: %w", err))
+		}
+	}
+	var sql *cratesql.Client
+	if (opts.Flush || opts.RecoveryRate != "" || opts.SnapshotCheck || opts.BlockCheck || opts.LatencyFactor > 0 || opts.NodeCount) && !opts.DryRun {
+		if sql, err = cratesql.Connect(ctx, c, t.Namespace, t.Name); err != nil {
+			return fail(fmt.Errorf("opening SQL connection: %w", err))
+		}
+		defer sql.Close()
+	}
+	if opts.Policy != nil && !opts.DryRun {
+		if err := cp.checkPolicy(ctx, c, t, cl, sql); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.BlockCheck && sql != nil {
+		if err := writeBlockCheck(ctx, sql); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.SnapshotCheck && sql != nil {
+		if err := snapshotGate(ctx, sql, t, opts.SnapshotWait); err != nil {
+			if errors.Is(err, errSnapshotInProgress) {
+				cp.explainf("skip", t, "reason", "snapshot-in-progress", "waited", opts.SnapshotWait)
+				cli.Warnf("%s: %v; skipping this cluster", t, err)
+				res.Status = "skipped"
+				res.Error = err.Error()
+				res.Finished = time.Now()
+				clusterSpan.End(nil)
+				return res
+			}
+			return fail(err)
+		}
+	}
+	if opts.RecoveryRate != "" && sql != nil {
+		restore, err := raiseRecoveryLimit(ctx, sql, t, opts.RecoveryRate)
+		if err != nil {
+			return fail(err)
+		}
+		defer restore(ctx)
+		res.Settings = append(res.Settings,
+			fmt.Sprintf("indices.recovery.max_bytes_per_sec=%s (transient, restored after the restart)", opts.RecoveryRate))
+	}
+	if err := cp.awaitApproval(ctx, c, t, len(pods)); err != nil {
+		return fail(err)
+	}
+	cli.Infof("%s: restarting %d pod(s)", t, len(pods))
+	cp.stampCampaign(ctx, c, t)
+	cp.emitEvent(ctx, c, t, "RestartStarted", fmt.Sprintf("rolling restart of %d pod(s) started", len(pods)))
+
+	// Data pods first, dedicated masters one-by-one last; within each
+	// group highest ordinal first, mirroring the StatefulSet's own
+	// rolling update order.
+	pods = cratedb.RestartOrder(pods, t.Name, opts.TierOrder)
+	for i := range pods {
+		switch cp.Control.Checkpoint(ctx) {
+		case ControlAbort:
+			res.Status = "skipped"
+			res.Error = "aborted by operator"
+			res.Finished = time.Now()
+			clusterSpan.End(nil)
+			return res
+		case ControlSkip:
+			cli.Infof("%s: skipping remaining pods on operator request", t)
+			res.Status = "skipped"
+			res.Error = "skipped by operator"
+			res.Finished = time.Now()
+			clusterSpan.End(nil)
+			return res
+		}
+		name := unstructured.NestedString(pods[i], "metadata", "name")
+		if cratedb.IsMasterPod(pods[i]) && !opts.Force && !opts.DryRun {
+			if err := masterPeersReady(ctx, c, cl, name); err != nil {
+				return fail(fmt.Errorf("pod %s: %w", name, err))
+			}
+		}
+		podOpts := opts
+		if mh, ok := opts.TierMinHealth[cratedb.PodPool(pods[i], t.Name)]; ok {
+			podOpts.MinHealth = mh
+		}
+		cp.prog.startPod(t, i+1, len(pods))
+		cp.explainf("restart-pod", t,
+			"pod", name, "position", fmt.Sprintf("%d/%d", i+1, len(pods)),
+			"pool", cratedb.PodPool(pods[i], t.Name), "min_health", podOpts.MinHealth,
+			"master", cratedb.IsMasterPod(pods[i]))
+		if opts.Flush && sql != nil {
+			if err := flushNode(ctx, sql, name); err != nil {
+				cli.Warnf("%s: %v (continuing; recovery will replay the translog)", t, err)
+			}
+		}
+		if opts.PromGate.Expr != "" && !opts.DryRun {
+			if err := opts.PromGate.Check(ctx, opts.PromURL); err != nil {
+				return fail(fmt.Errorf("before pod %s: %w", name, err))
+			}
+		}
+		if opts.CapacityCheck && !opts.DryRun {
+			if err := cp.awaitCapacity(ctx, c, t, pods[i], opts); err != nil {
+				return fail(fmt.Errorf("before pod %s: %w", name, err))
+			}
+		}
+		if opts.PreemptionCheck && !opts.DryRun {
+			if err := cp.checkPreemption(ctx, c, t, pods[i]); err != nil {
+				return fail(fmt.Errorf("before pod %s: %w", name, err))
+			}
+		}
+		var baseline queryStats
+		if opts.LatencyFactor > 0 && sql != nil {
+			if baseline, err = sampleQueryStats(ctx, sql); err != nil {
+				cli.Warnf("%s: %v", t, err)
+			}
+		}
+		pr := PodResult{Pod: name, Started: time.Now()}
+		attempts, err := cp.restartPod(ctx, c, t, name, clusterSpan, podOpts)
+		if attempts > 1 {
+			pr.Attempts = attempts
+		}
+		if err == nil && opts.NodeCount && sql != nil {
+			want := cl.DataNodes()
+			if cl.Spec.Nodes.Master != nil {
+				want += cl.Spec.Nodes.Master.Replicas
+			}
+			err = verifyNodeJoin(ctx, sql, name, want)
+		}
+		if err == nil && opts.LatencyFactor > 0 && sql != nil {
+			after, serr := sampleQueryStats(ctx, sql)
+			if serr != nil {
+				cli.Warnf("%s: %v", t, serr)
+			} else {
+				err = checkRegression(baseline, after, opts.LatencyFactor)
+			}
+		}
+		pr.Finished = time.Now()
+		if err != nil {
+			pr.Error = err.Error()
+			res.Pods = append(res.Pods, pr)
+			if errors.Is(err, ErrHealthTimeout) && cp.Options.OnTimeout == TimeoutSkip {
+				cli.Warnf("%s: pod %s: %v; skipping the rest of this cluster", t, name, err)
+				res.Status = "skipped"
+				res.Error = err.Error()
+				res.Finished = time.Now()
+				clusterSpan.End(err)
+				return res
+			}
+			return fail(fmt.Errorf("pod %s: %w", name, err))
+		}
+		res.Pods = append(res.Pods, pr)
+		cp.prog.podDone(pr.Finished.Sub(pr.Started))
+		cli.Infof("%s: pod %s back in %s", t, name, pr.Finished.Sub(pr.Started).Round(time.Second))
+		if i < len(pods)-1 && !opts.DryRun {
+			// Pace the next pod off how long this one took: a cluster
+			// recovering slowly earns a proportionally longer breather.
+			pause := opts.PodPause
+			if adaptive := time.Duration(opts.AdaptivePace * float64(pr.Finished.Sub(pr.Started))); adaptive > pause {
+				pause = adaptive
+			}
+			if pause > 0 {
+				cp.explainf("pace", t, "pause", pause.Round(time.Second),
+					"last_recovery", pr.Finished.Sub(pr.Started).Round(time.Second), "factor", opts.AdaptivePace)
+				cli.Infof("%s: waiting %s before the next pod", t, pause.Round(time.Second))
+				if err := cli.Sleep(ctx, pause); err != nil {
+					return fail(err)
+				}
+			}
+		}
+	}
+	if opts.VerifyRev && !opts.DryRun {
+		if err := verifyRevisions(ctx, c, cl); err != nil {
+			return fail(err)
+		}
+	}
+	res.Status = "succeeded"
+	res.Finished = time.Now()
+	clusterSpan.End(nil)
+	cp.emitEvent(ctx, c, t, "RestartSucceeded",
+		fmt.Sprintf("rolling restart finished in %s", res.Finished.Sub(res.Started).Round(time.Second)))
+	return res
+}
+
+// RestartPod deletes a single pod and waits for it to return Ready and
+// for the cluster to recover, applying the campaign's timeouts and
+// health gates. It backs the drill command, which paces individual pod
+// restarts on its own cadence instead of rolling a whole cluster.
+func (cp *Campaign) RestartPod(ctx context.Context, c *kube.Client, t Target, pod string) error {
+	_, err := cp.restartPod(ctx, c, t, pod, nil, cp.Options)
+	return err
+}
+
+func (cp *Campaign) restartPod(ctx context.Context, c *kube.Client, t Target, pod string, clusterSpan *tracing.Span, opts Options) (attempts int, err error) {
+	span := cp.Tracer.Start(clusterSpan, "pod", tracing.String("pod.name", pod))
+	defer func() { span.End(err) }()
+	attempts = 1
+	if opts.DryRun {
+		cli.Infof("%s: would delete pod %s", t, pod)
+		return attempts, nil
+	}
+	cli.Infof("%s: deleting pod %s", t, pod)
+	path := kube.ResourcePath("v1", t.Namespace, "pods", pod)
+	var uid string
+	var existing map[string]any
+	if err := c.Get(ctx, path, &existing); err == nil {
+		uid = unstructured.NestedString(existing, "metadata", "uid")
+	}
+	if err := c.Delete(ctx, path); err != nil {
+		return attempts, fmt.Errorf("deleting: %w", err)
+	}
+	cp.Stream.emit(StreamEvent{Type: "pod-deleted", Campaign: cp.ID, Cluster: t.String(), Pod: pod})
+	if err := cp.waitPodReady(ctx, c, path, uid, opts); err != nil {
+		return attempts, err
+	}
+	if opts.ProbeHTTP {
+		if err := probeHTTP(ctx, c, t, pod, opts); err != nil {
+			return attempts, err
+		}
+	}
+
+	// The verification phase — health wait plus soak — may fail on an
+	// API hiccup or a briefly RED cluster; with VerifyRetries it gets
+	// re-run instead of failing the whole cluster. A genuine health
+	// timeout is not transient and goes to OnTimeout escalation.
+	for {
+		err = cp.verifyPod(ctx, c, t, pod, span, opts)
+		if err == nil || errors.Is(err, ErrHealthTimeout) || ctx.Err() != nil || attempts > opts.VerifyRetries {
+			break
+		}
+		cli.Warnf("%s: pod %s: verification attempt %d/%d failed: %v; retrying",
+			t, pod, attempts, opts.VerifyRetries+1, err)
+		if serr := cli.Sleep(ctx, opts.Poll); serr != nil {
+			break
+		}
+		attempts++
+	}
+	return attempts, err
+}
+
+// verifyPod is one attempt at the post-restart verification: wait for
+// cluster health (with OnTimeout escalation), then soak.
+func (cp *Campaign) verifyPod(ctx context.Context, c *kube.Client, t Target, pod string, span *tracing.Span, opts Options) error {
+	hs := cp.Tracer.Start(span, "health-wait")
+	waitStarted := time.Now()
+	err := cp.waitHealthy(ctx, c, t, opts)
+	cp.explainf("health-wait", t,
+		"pod", pod, "waited", time.Since(waitStarted).Round(time.Second),
+		"min_health", opts.MinHealth, "timed_out", errors.Is(err, ErrHealthTimeout))
+	for errors.Is(err, ErrHealthTimeout) && ctx.Err() == nil {
+		switch opts.OnTimeout {
+		case TimeoutPause:
+			cli.Warnf("%s: %v", t, err)
+			if cli.Confirm(fmt.Sprintf("%s: keep waiting another %s for %s", t, opts.HealthTimeout, opts.MinHealth)) {
+				err = cp.waitHealthy(ctx, c, t, opts)
+				continue
+			}
+		case TimeoutForce:
+			cli.Warnf("%s: %v", t, err)
+			if cli.Confirm(fmt.Sprintf("%s: continue restarting WITHOUT %s", t, opts.MinHealth)) {
+				err = nil
+			}
+		}
+		break
+	}
+	hs.End(err)
+	if err == nil {
+		cp.Stream.emit(StreamEvent{Type: "health-green", Campaign: cp.ID,
+			Cluster: t.String(), Pod: pod, Status: opts.MinHealth})
+	}
+	if err == nil && opts.PostReadySoak > 0 {
+		err = cp.soak(ctx, c, t, pod, opts)
+	}
+	return err
+}
+
+// soak keeps the gate closed for PostReadySoak after the pod is Ready
+// and health has recovered, then re-checks both. A node that crashes
+// shortly after rejoining — heap pressure, a bad disk, a poisoned
+// shard — is caught here instead of three clusters later.
+func (cp *Campaign) soak(ctx context.Context, c *kube.Client, t Target, pod string, opts Options) error {
+	cli.Infof("%s: pod %s Ready; soaking for %s", t, pod, opts.PostReadySoak)
+	if err := cli.Sleep(ctx, opts.PostReadySoak); err != nil {
+		return err
+	}
+	var p map[string]any
+	if err := c.Get(ctx, kube.ResourcePath("v1", t.Namespace, "pods", pod), &p); err != nil {
+		return fmt.Errorf("pod disappeared during soak: %w", err)
+	}
+	if !cratedb.PodReady(p) {
+		return fmt.Errorf("pod %s fell out of Ready during the %s soak", pod, opts.PostReadySoak)
+	}
+	cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+	if err != nil {
+		return err
+	}
+	if h := cl.Health(); !cratedb.HealthAtLeast(h, opts.MinHealth) {
+		return fmt.Errorf("health fell to %s during the %s soak", h, opts.PostReadySoak)
+	}
+	return nil
+}
+
+// masterPeersReady verifies every other dedicated master pod is Ready
+// before target is taken down, so a restart never removes voting
+// quorum on top of an already-degraded master pool.
+func masterPeersReady(ctx context.Context, c *kube.Client, cl cratedb.Cluster, target string) error {
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		if name == target || !cratedb.IsMasterPod(pod) {
+			continue
+		}
+		if !cratedb.PodReady(pod) {
+			return fmt.Errorf("master pod %s is not Ready; restarting would risk quorum", name)
+		}
+	}
+	return nil
+}
+
+// optionsFor applies a cluster's own annotation overrides
+// (min-health, health-timeout, pod-timeout under the tool's annotation
+// prefix) to the campaign defaults, so heterogeneous fleets run in one
+// invocation.
+func (cp *Campaign) optionsFor(t Target, cl cratedb.Cluster) Options {
+	opts := cp.Options
+	if v := cl.Annotation("min-health"); v != "" {
+		if cratedb.KnownHealth(v) {
+			opts.MinHealth = v
+		} else {
+			cli.Warnf("%s: ignoring min-health annotation %q", t, v)
+		}
+	}
+	if v := cl.Annotation("health-timeout"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			opts.HealthTimeout = d
+		} else {
+			cli.Warnf("%s: ignoring health-timeout annotation %q", t, v)
+		}
+	}
+	if v := cl.Annotation("pod-timeout"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			opts.PodTimeout = d
+		} else {
+			cli.Warnf("%s: ignoring pod-timeout annotation %q", t, v)
+		}
+	}
+	return opts
+}
+
+// waitPodReady polls until the pod exists with a different UID and
+// reports Ready.
+func (cp *Campaign) waitPodReady(ctx context.Context, c *kube.Client, path, oldUID string, opts Options) error {
+	deadline := time.Now().Add(opts.PodTimeout)
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for pod Ready", opts.PodTimeout)
+		}
+		var pod map[string]any
+		err := c.Get(ctx, path, &pod)
+		switch {
+		case kube.IsNotFound(err):
+			// still terminating or not yet recreated
+		case err != nil:
+			return err
+		default:
+			uid := unstructured.NestedString(pod, "metadata", "uid")
+			if uid != oldUID && cratedb.PodReady(pod) {
+				return nil
+			}
+		}
+		if err := cli.Sleep(ctx, opts.Poll); err != nil {
+			return err
+		}
+	}
+}
+
+// waitHealthy waits until the operator reports at least opts.MinHealth
+// (GREEN by default). Status updates arrive over a watch scoped to the
+// one CR, so the campaign reacts the moment the operator writes the
+// status instead of polling the apiserver every few seconds for hours.
+func (cp *Campaign) waitHealthy(ctx context.Context, c *kube.Client, t Target, opts Options) error {
+	wctx, cancel := context.WithTimeout(ctx, opts.HealthTimeout)
+	defer cancel()
+	path := kube.ResourcePath(cratedb.APIVersion, t.Namespace, cratedb.Plural, "")
+	q := url.Values{"fieldSelector": []string{"metadata.name=" + t.Name}}
+	for {
+		// A fresh GET covers the already-healthy case and any update
+		// that slipped between watch streams.
+		cl, err := cratedb.Get(wctx, c, t.Namespace, t.Name)
+		if err != nil {
+			if wctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		if cratedb.HealthAtLeast(cl.Health(), opts.MinHealth) {
+			return nil
+		}
+		cli.Debugf("%s: health %s, watching", t, cl.Health())
+		if healthy := cp.watchUntilHealthy(wctx, c, path, q, opts.MinHealth); healthy {
+			return nil
+		}
+		if wctx.Err() != nil {
+			break
+		}
+		// The apiserver closes long watches routinely; re-check and
+		// reopen.
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w (%s) after %s", ErrHealthTimeout, opts.MinHealth, opts.HealthTimeout)
+}
+
+// watchUntilHealthy consumes one watch stream, reporting whether a
+// status update at or above minHealth arrived before the stream ended.
+func (cp *Campaign) watchUntilHealthy(ctx context.Context, c *kube.Client, path string, q url.Values, minHealth string) bool {
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan kube.WatchEvent)
+	done := make(chan error, 1)
+	go func() { done <- c.Watch(wctx, path, q, events) }()
+	for {
+		select {
+		case ev := <-events:
+			if cratedb.HealthAtLeast(cratedb.HealthOf(ev.Object), minHealth) {
+				return true
+			}
+		case err := <-done:
+			if err != nil && ctx.Err() == nil {
+				cli.Debugf("health watch: %v", err)
+			}
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+)
+
+// canaryWindow is the sys.jobs_log lookback used when sampling query
+// behaviour before and after a pod restart.
+const canaryWindow = "5 minutes"
+
+// canaryMinSamples is the least number of logged jobs required before
+// a latency comparison means anything; below it only the error rate is
+// considered.
+const canaryMinSamples = 10
+
+// queryStats summarizes recent query behaviour from sys.jobs_log.
+type queryStats struct {
+	AvgMillis float64
+	ErrorRate float64
+	Samples   int64
+}
+
+// sampleQueryStats reads average latency and error rate over the last
+// canaryWindow from sys.jobs_log. Clusters with stats disabled report
+// zero samples, which disables the latency comparison downstream.
+func sampleQueryStats(ctx context.Context, sql *cratesql.Client) (queryStats, error) {
+	res, err := sql.Exec(ctx, fmt.Sprintf(
+		"SELECT avg(ended - started), sum(CASE WHEN error IS NULL THEN 0 ELSE 1 END), count(*) FROM sys.jobs_log WHERE ended > now() - '%s'::INTERVAL", canaryWindow))
+	if err != nil {
+		return queryStats{}, fmt.Errorf("sampling sys.jobs_log: %w", err)
+	}
+	var st queryStats
+	if len(res.Rows) > 0 {
+		row := res.Rows[0]
+		st.AvgMillis, _ = row[0].(float64)
+		errs, _ := row[1].(float64)
+		count, _ := row[2].(float64)
+		st.Samples = int64(count)
+		if count > 0 {
+			st.ErrorRate = errs / count
+		}
+	}
+	return st, nil
+}
+
+// checkRegression compares query behaviour after a pod restart against
+// the baseline taken before it. factor bounds the tolerated latency
+// growth (1.5 = 50% slower); the error rate may grow by at most five
+// percentage points regardless of factor, since a latency-neutral
+// error spike is just as much a regression.
+func checkRegression(before, after queryStats, factor float64) error {
+	if after.ErrorRate > before.ErrorRate+0.05 {
+		return fmt.Errorf("query error rate rose from %.1f%% to %.1f%% over the last %s",
+			before.ErrorRate*100, after.ErrorRate*100, canaryWindow)
+	}
+	if before.Samples >= canaryMinSamples && after.Samples >= canaryMinSamples &&
+		before.AvgMillis > 0 && after.AvgMillis > before.AvgMillis*factor {
+		return fmt.Errorf("average query latency rose from %.0fms to %.0fms (more than %.1fx) over the last %s",
+			before.AvgMillis, after.AvgMillis, factor, canaryWindow)
+	}
+	return nil
+}
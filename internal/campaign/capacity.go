@@ -0,0 +1,198 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// The capacity gate guards against deleting a pod the cluster cannot
+// reschedule: on autoscaled node pools the replacement can sit Pending
+// until a node arrives, and if none does the health gate never
+// recovers. Before each delete it compares the pod's requests against
+// node allocatable minus the requests of running pods, counting the
+// pod's current node as free since the delete releases its slot.
+
+// awaitCapacity holds until some node could schedule the pod's
+// replacement, polling up to opts.CapacityWait so an autoscaler has
+// time to add a node, then fails the cluster.
+func (cp *Campaign) awaitCapacity(ctx context.Context, c *kube.Client, t Target, pod map[string]any, opts Options) error {
+	name := unstructured.NestedString(pod, "metadata", "name")
+	deadline := time.Now().Add(opts.CapacityWait)
+	for {
+		ok, reason, err := capacityFor(ctx, c, pod)
+		if err != nil {
+			return fmt.Errorf("capacity check: %w", err)
+		}
+		if ok {
+			cp.explainf("capacity", t, "pod", name, "fit", reason)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("capacity check: %s; deleting pod %s would leave it Pending", reason, name)
+		}
+		cli.Warnf("%s: holding before deleting %s: %s", t, name, reason)
+		if err := cli.Sleep(ctx, opts.Poll); err != nil {
+			return err
+		}
+	}
+}
+
+// capacityFor reports whether the replacement for pod could be
+// scheduled right now, with a short reason either way. Pods already
+// Pending Unschedulable count as a hard signal: the replacement would
+// queue behind them.
+func capacityFor(ctx context.Context, c *kube.Client, pod map[string]any) (bool, string, error) {
+	needCPU, needMem := podRequests(pod)
+	selfNS := unstructured.NestedString(pod, "metadata", "namespace")
+	selfName := unstructured.NestedString(pod, "metadata", "name")
+
+	nodes, err := c.List(ctx, kube.ResourcePath("v1", "", "nodes", ""), nil)
+	if err != nil {
+		return false, "", fmt.Errorf("listing nodes: %w", err)
+	}
+	allPods, err := c.List(ctx, kube.ResourcePath("v1", "", "pods", ""), nil)
+	if err != nil {
+		return false, "", fmt.Errorf("listing pods: %w", err)
+	}
+
+	type usage struct{ cpu, mem int64 }
+	used := map[string]*usage{}
+	unschedulable := 0
+	for _, p := range allPods {
+		phase := unstructured.NestedString(p, "status", "phase")
+		if phase == "Succeeded" || phase == "Failed" {
+			continue
+		}
+		if isUnschedulable(p) {
+			unschedulable++
+			continue
+		}
+		if unstructured.NestedString(p, "metadata", "namespace") == selfNS &&
+			unstructured.NestedString(p, "metadata", "name") == selfName {
+			continue // the delete frees this pod's own slot
+		}
+		node := unstructured.NestedString(p, "spec", "nodeName")
+		if node == "" {
+			continue
+		}
+		cpu, mem := podRequests(p)
+		u := used[node]
+		if u == nil {
+			u = &usage{}
+			used[node] = u
+		}
+		u.cpu += cpu
+		u.mem += mem
+	}
+	if unschedulable > 0 {
+		return false, fmt.Sprintf("%d pod(s) are already Pending Unschedulable; the replacement would queue behind them", unschedulable), nil
+	}
+	for _, n := range nodes {
+		if unstructured.NestedBool(n, "spec", "unschedulable") || !nodeReady(n) {
+			continue
+		}
+		name := unstructured.NestedString(n, "metadata", "name")
+		u := used[name]
+		if u == nil {
+			u = &usage{}
+		}
+		freeCPU := parseCPU(unstructured.NestedString(n, "status", "allocatable", "cpu")) - u.cpu
+		freeMem := parseMemory(unstructured.NestedString(n, "status", "allocatable", "memory")) - u.mem
+		if freeCPU >= needCPU && freeMem >= needMem {
+			return true, fmt.Sprintf("node %s has %s CPU and %s memory free", name, fmtMilliCPU(freeCPU), fmtMem(freeMem)), nil
+		}
+	}
+	return false, fmt.Sprintf("no schedulable node has %s CPU and %s memory free for the replacement",
+		fmtMilliCPU(needCPU), fmtMem(needMem)), nil
+}
+
+// podRequests sums the container resource requests of a pod, as
+// millicores and bytes.
+func podRequests(pod map[string]any) (cpuMilli, memBytes int64) {
+	for _, container := range unstructured.NestedSlice(pod, "spec", "containers") {
+		m, ok := container.(map[string]any)
+		if !ok {
+			continue
+		}
+		cpuMilli += parseCPU(unstructured.NestedString(m, "resources", "requests", "cpu"))
+		memBytes += parseMemory(unstructured.NestedString(m, "resources", "requests", "memory"))
+	}
+	return cpuMilli, memBytes
+}
+
+// isUnschedulable reports whether a pod is Pending because the
+// scheduler found no node for it.
+func isUnschedulable(pod map[string]any) bool {
+	if unstructured.NestedString(pod, "status", "phase") != "Pending" {
+		return false
+	}
+	for _, cond := range unstructured.NestedSlice(pod, "status", "conditions") {
+		m, ok := cond.(map[string]any)
+		if !ok {
+			continue
+		}
+		if unstructured.NestedString(m, "type") == "PodScheduled" &&
+			unstructured.NestedString(m, "status") == "False" &&
+			unstructured.NestedString(m, "reason") == "Unschedulable" {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeReady(node map[string]any) bool {
+	for _, cond := range unstructured.NestedSlice(node, "status", "conditions") {
+		m, ok := cond.(map[string]any)
+		if ok && unstructured.NestedString(m, "type") == "Ready" {
+			return unstructured.NestedString(m, "status") == "True"
+		}
+	}
+	return false
+}
+
+// parseCPU parses a Kubernetes CPU quantity into millicores.
+func parseCPU(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	if v, found := strings.CutSuffix(s, "m"); found {
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return int64(f * 1000)
+}
+
+// memSuffixes are the quantity suffixes Kubernetes uses for memory.
+var memSuffixes = []struct {
+	suffix string
+	factor float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40}, {"Pi", 1 << 50},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"P", 1e15},
+}
+
+// parseMemory parses a Kubernetes memory quantity into bytes.
+func parseMemory(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	for _, m := range memSuffixes {
+		if v, found := strings.CutSuffix(s, m.suffix); found {
+			f, _ := strconv.ParseFloat(v, 64)
+			return int64(f * m.factor)
+		}
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return int64(f)
+}
+
+func fmtMilliCPU(m int64) string { return fmt.Sprintf("%dm", m) }
+func fmtMem(b int64) string      { return fmt.Sprintf("%dMi", b/(1<<20)) }
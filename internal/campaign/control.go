@@ -0,0 +1,146 @@
+package campaign
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+// Control lets an operator steer a running campaign from outside the
+// process: pause after the current pod, skip the rest of the current
+// cluster, or abort the campaign gracefully, all without killing the
+// process and losing recorded results.
+type Control struct {
+	mu     sync.Mutex
+	paused bool
+	skip   bool
+	abort  bool
+}
+
+// Checkpoint outcomes.
+const (
+	ControlContinue = iota
+	ControlSkip
+	ControlAbort
+)
+
+// NewControl returns a Control in the running state.
+func NewControl() *Control { return &Control{} }
+
+// Command executes one control command and returns a reply line.
+func (ct *Control) Command(cmd string) string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	switch strings.TrimSpace(cmd) {
+	case "pause":
+		ct.paused = true
+		return "ok: pausing after the current pod"
+	case "resume":
+		ct.paused = false
+		return "ok: resuming"
+	case "skip":
+		ct.skip = true
+		return "ok: skipping the rest of the current cluster"
+	case "abort":
+		ct.abort = true
+		ct.paused = false
+		return "ok: aborting after the current pod"
+	case "status":
+		switch {
+		case ct.abort:
+			return "aborting"
+		case ct.paused:
+			return "paused"
+		default:
+			return "running"
+		}
+	default:
+		return "error: unknown command (pause, resume, skip, abort, status)"
+	}
+}
+
+// Checkpoint is called between pods. It blocks while the campaign is
+// paused and reports whether to continue, skip the current cluster, or
+// abort.
+func (ct *Control) Checkpoint(ctx context.Context) int {
+	if ct == nil {
+		return ControlContinue
+	}
+	for {
+		ct.mu.Lock()
+		abort, skip, paused := ct.abort, ct.skip, ct.paused
+		ct.skip = false
+		ct.mu.Unlock()
+		switch {
+		case abort:
+			return ControlAbort
+		case skip:
+			return ControlSkip
+		case !paused:
+			return ControlContinue
+		}
+		cli.Infof("campaign paused; waiting for resume")
+		for paused {
+			if err := cli.Sleep(ctx, time.Second); err != nil {
+				return ControlAbort
+			}
+			ct.mu.Lock()
+			paused, abort = ct.paused, ct.abort
+			ct.mu.Unlock()
+			if abort {
+				return ControlAbort
+			}
+		}
+		cli.Infof("campaign resumed")
+	}
+}
+
+// Aborted reports whether an abort was requested.
+func (ct *Control) Aborted() bool {
+	if ct == nil {
+		return false
+	}
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.abort
+}
+
+// Listen serves control commands on a Unix socket, one line per
+// command, until ctx is cancelled. The socket file is removed on
+// shutdown.
+func (ct *Control) Listen(ctx context.Context, path string) error {
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("control socket: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		os.Remove(path)
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					fmt.Fprintln(conn, ct.Command(sc.Text()))
+				}
+			}(conn)
+		}
+	}()
+	cli.Infof("control socket at %s (commands: pause, resume, skip, abort, status)", path)
+	return nil
+}
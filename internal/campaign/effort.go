@@ -0,0 +1,64 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// effortOrder sorts targets by estimated recovery effort, largest
+// first. Under a parallelism limit this is longest-processing-time
+// scheduling: the long-pole clusters start immediately while small
+// ones fill the remaining lanes, minimizing campaign wall-clock time.
+// Clusters whose effort cannot be measured sort last and the campaign
+// proceeds without them being weighted.
+func effortOrder(ctx context.Context, c *kube.Client, targets []Target) []Target {
+	type weighted struct {
+		t      Target
+		effort float64
+	}
+	ws := make([]weighted, 0, len(targets))
+	for _, t := range targets {
+		effort, err := recoveryEffort(ctx, c, t)
+		if err != nil {
+			cli.Warnf("%s: estimating recovery effort: %v", t, err)
+		}
+		ws = append(ws, weighted{t: t, effort: effort})
+	}
+	sort.SliceStable(ws, func(i, j int) bool { return ws[i].effort > ws[j].effort })
+	out := make([]Target, len(ws))
+	for i, w := range ws {
+		out[i] = w.t
+		cli.Debugf("%s: estimated recovery effort %.0f bytes/node", w.t, w.effort)
+	}
+	return out
+}
+
+// recoveryEffort estimates how much one pod restart of t costs: total
+// primary shard bytes divided by the node count, i.e. the data volume
+// each node's departure puts back into recovery.
+func recoveryEffort(ctx context.Context, c *kube.Client, t Target) (float64, error) {
+	sql, err := cratesql.Connect(ctx, c, t.Namespace, t.Name)
+	if err != nil {
+		return 0, fmt.Errorf("opening SQL connection: %w", err)
+	}
+	defer sql.Close()
+	res, err := sql.Exec(ctx,
+		`SELECT coalesce(sum(size), 0), (SELECT count(*) FROM sys.nodes) FROM sys.shards WHERE "primary" = true`)
+	if err != nil {
+		return 0, fmt.Errorf("reading sys.shards: %w", err)
+	}
+	if len(res.Rows) == 0 {
+		return 0, nil
+	}
+	bytes, _ := res.Rows[0][0].(float64)
+	nodes, _ := res.Rows[0][1].(float64)
+	if nodes == 0 {
+		return 0, nil
+	}
+	return bytes / nodes, nil
+}
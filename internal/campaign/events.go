@@ -0,0 +1,79 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// emitEvent posts a Kubernetes Event on the CrateDB object carrying
+// the campaign ID, so anyone running kubectl describe on the CR sees
+// which campaign touched it and observability systems can correlate
+// Events with the tool's logs and reports. Event emission is best
+// effort; a cluster restart never fails because of it.
+func (cp *Campaign) emitEvent(ctx context.Context, c *kube.Client, t Target, reason, message string) {
+	if cp.Options.DryRun {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	msg := fmt.Sprintf("%s (campaign %s by %s)", message, cp.ID, cp.Operator)
+	if cp.Options.Reason != "" {
+		msg += ": " + cp.Options.Reason
+	}
+	event := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]any{
+			"generateName": "crt-",
+			"namespace":    t.Namespace,
+		},
+		"involvedObject": map[string]any{
+			"apiVersion": cratedb.APIVersion,
+			"kind":       "CrateDB",
+			"namespace":  t.Namespace,
+			"name":       t.Name,
+		},
+		"reason":         reason,
+		"message":        msg,
+		"type":           "Normal",
+		"firstTimestamp": now,
+		"lastTimestamp":  now,
+		"count":          1,
+		"source":         map[string]any{"component": kube.FieldManager},
+	}
+	if err := c.Create(ctx, kube.ResourcePath("v1", t.Namespace, "events", ""), event); err != nil {
+		cli.Debugf("%s: emitting event: %v", t, err)
+	}
+}
+
+// stampCampaign writes the campaign ID into the cluster's last-campaign
+// annotation, again best effort.
+func (cp *Campaign) stampCampaign(ctx context.Context, c *kube.Client, t Target) {
+	if cp.Options.DryRun {
+		return
+	}
+	annotations := map[string]any{
+		cratedb.AnnotationPrefix + "last-campaign":    cp.ID,
+		cratedb.AnnotationPrefix + "last-campaign-by": cp.Operator,
+	}
+	if cp.Options.Reason != "" {
+		annotations[cratedb.AnnotationPrefix+"last-campaign-reason"] = cp.Options.Reason
+	}
+	apply := map[string]any{
+		"apiVersion": cratedb.APIVersion,
+		"kind":       "CrateDB",
+		"metadata": map[string]any{
+			"name":        t.Name,
+			"namespace":   t.Namespace,
+			"annotations": annotations,
+		},
+	}
+	path := kube.ResourcePath(cratedb.APIVersion, t.Namespace, cratedb.Plural, t.Name)
+	if err := c.Apply(ctx, path, apply); err != nil {
+		cli.Debugf("%s: stamping campaign annotation: %v", t, err)
+	}
+}
@@ -0,0 +1,37 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// httpProbeTimeout bounds the wait for a Ready pod's admin port to
+// answer; a healthy node serves HTTP well before it turns Ready.
+const httpProbeTimeout = 2 * time.Minute
+
+// probeHTTP waits until the restarted pod answers on its HTTP admin
+// port. Kubernetes readiness only proves the probe endpoint inside the
+// container; polling the admin API from outside also exercises the
+// path operators and load balancers actually depend on.
+func probeHTTP(ctx context.Context, c *kube.Client, t Target, pod string, opts Options) error {
+	deadline := time.Now().Add(httpProbeTimeout)
+	for {
+		version, err := cratesql.ProbePod(ctx, c, t.Namespace, pod)
+		if err == nil {
+			cli.Debugf("%s: pod %s answers on the admin port (version %s)", t, pod, version)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pod %s is Ready but its HTTP admin port is not answering: %w", pod, err)
+		}
+		cli.Debugf("%s: pod %s admin port not answering yet: %v", t, pod, err)
+		if serr := cli.Sleep(ctx, opts.Poll); serr != nil {
+			return serr
+		}
+	}
+}
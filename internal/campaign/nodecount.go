@@ -0,0 +1,48 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+)
+
+// nodeJoinTimeout bounds the wait for a restarted pod to appear in
+// sys.nodes; a healthy node joins within seconds of turning Ready.
+const nodeJoinTimeout = 2 * time.Minute
+
+// verifyNodeJoin confirms the CrateDB process behind pod actually
+// rejoined the cluster: sys.nodes reports the expected node count and
+// includes the pod's hostname. A pod can pass its readiness probe yet
+// fail discovery and sit outside the cluster; health alone misses
+// that.
+func verifyNodeJoin(ctx context.Context, sql *cratesql.Client, pod string, want int64) error {
+	deadline := time.Now().Add(nodeJoinTimeout)
+	for {
+		res, err := sql.Exec(ctx,
+			"SELECT count(*), coalesce(sum(CASE WHEN hostname = ? THEN 1 ELSE 0 END), 0) FROM sys.nodes", pod)
+		if err != nil {
+			return fmt.Errorf("checking sys.nodes: %w", err)
+		}
+		var count, joined float64
+		if len(res.Rows) > 0 {
+			count, _ = res.Rows[0][0].(float64)
+			joined, _ = res.Rows[0][1].(float64)
+		}
+		if int64(count) == want && joined > 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if joined == 0 {
+				return fmt.Errorf("pod %s is Ready but absent from sys.nodes after %s", pod, nodeJoinTimeout)
+			}
+			return fmt.Errorf("sys.nodes reports %d node(s), want %d", int64(count), want)
+		}
+		cli.Debugf("%s: sys.nodes has %d/%d node(s), waiting for rejoin", pod, int64(count), want)
+		if err := cli.Sleep(ctx, 5*time.Second); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package campaign
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// filterPods keeps the pods matching any of the selectors. A selector
+// is a full pod name, a StatefulSet ordinal ("3"), or an inclusive
+// ordinal range ("0-2"); ordinals apply across every pool of the
+// cluster.
+func filterPods(pods []map[string]any, selectors []string) []map[string]any {
+	var out []map[string]any
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		ordinal := cratedb.PodOrdinal(pod)
+		for _, sel := range selectors {
+			if matchPodSelector(sel, name, ordinal) {
+				out = append(out, pod)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// excludePods drops the pods matching any of the selectors, returning
+// the kept pods and the names of those left alone.
+func excludePods(pods []map[string]any, selectors []string) (kept []map[string]any, skipped []string) {
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		ordinal := cratedb.PodOrdinal(pod)
+		excluded := false
+		for _, sel := range selectors {
+			if matchPodSelector(sel, name, ordinal) {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			skipped = append(skipped, name)
+		} else {
+			kept = append(kept, pod)
+		}
+	}
+	return kept, skipped
+}
+
+// matchPodSelector reports whether one selector matches a pod.
+func matchPodSelector(sel, name string, ordinal int) bool {
+	if sel == name {
+		return true
+	}
+	if lo, hi, found := strings.Cut(sel, "-"); found {
+		a, err1 := strconv.Atoi(lo)
+		b, err2 := strconv.Atoi(hi)
+		return err1 == nil && err2 == nil && ordinal >= a && ordinal <= b
+	}
+	n, err := strconv.Atoi(sel)
+	return err == nil && ordinal == n
+}
@@ -0,0 +1,48 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// checkPolicy evaluates the -policy expression against one cluster
+// during preflight. The expression sees the raw CR as its root plus a
+// few computed fields: nodes (the spec's total replica count),
+// statefulset (the first owned StatefulSet object), and sql.nodes /
+// sql.max_severity when a SQL connection is already open for other
+// gates. A cluster the policy rejects fails before any pod is touched.
+func (cp *Campaign) checkPolicy(ctx context.Context, c *kube.Client, t Target, cl cratedb.Cluster, sql *cratesql.Client) error {
+	obj := make(map[string]any, len(cl.Obj)+3)
+	for k, v := range cl.Obj {
+		obj[k] = v
+	}
+	want := cl.DataNodes()
+	if cl.Spec.Nodes.Master != nil {
+		want += cl.Spec.Nodes.Master.Replicas
+	}
+	obj["nodes"] = float64(want)
+	if stss, err := cratedb.StatefulSets(ctx, c, []cratedb.Cluster{cl}); err == nil {
+		if list := stss[cl.Namespace+"/"+cl.Name]; len(list) > 0 {
+			obj["statefulset"] = list[0]
+		}
+	}
+	if sql != nil {
+		res, err := sql.Exec(ctx,
+			"SELECT (SELECT count(*) FROM sys.nodes), coalesce(max(severity), 1) FROM sys.health")
+		if err != nil {
+			cli.Debugf("%s: policy SQL inputs: %v", t, err)
+		} else if len(res.Rows) > 0 {
+			obj["sql"] = map[string]any{"nodes": res.Rows[0][0], "max_severity": res.Rows[0][1]}
+		}
+	}
+	if !cp.Options.Policy.Match(obj) {
+		cp.explainf("refuse", t, "reason", "policy", "policy", cp.Options.Policy)
+		return fmt.Errorf("policy %q not satisfied", cp.Options.Policy)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// errSnapshotInProgress marks a cluster that is snapshotting or
+// restoring; restarting nodes mid-snapshot fails the backup silently.
+var errSnapshotInProgress = errors.New("snapshot or restore in progress")
+
+// snapshotGate waits up to wait for running snapshot and restore
+// operations to finish, then returns errSnapshotInProgress if any are
+// still active so the caller can skip the cluster instead of breaking
+// its backup.
+func snapshotGate(ctx context.Context, sql *cratesql.Client, t Target, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		busy, err := snapshotActivity(ctx, sql)
+		if err != nil {
+			return err
+		}
+		if busy == "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", errSnapshotInProgress, busy)
+		}
+		cli.Infof("%s: %s; waiting for it to finish", t, busy)
+		if err := cli.Sleep(ctx, 30*time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// snapshotActivity describes running snapshot or restore operations,
+// or "" when the cluster is idle.
+func snapshotActivity(ctx context.Context, sql *cratesql.Client) (string, error) {
+	count := func(stmt string) (int64, error) {
+		res, err := sql.Exec(ctx, stmt)
+		if err != nil {
+			return 0, fmt.Errorf("snapshot gate: %w", err)
+		}
+		if len(res.Rows) > 0 {
+			if f, ok := res.Rows[0][0].(float64); ok {
+				return int64(f), nil
+			}
+		}
+		return 0, nil
+	}
+	snaps, err := count("SELECT count(*) FROM sys.snapshots WHERE state = 'IN_PROGRESS'")
+	if err != nil {
+		return "", err
+	}
+	if snaps > 0 {
+		return fmt.Sprintf("%d snapshot(s) in progress", snaps), nil
+	}
+	restores, err := count("SELECT count(*) FROM sys.snapshot_restore WHERE state IN ('INIT', 'STARTED')")
+	if err != nil {
+		return "", err
+	}
+	if restores > 0 {
+		return fmt.Sprintf("%d restore(s) in progress", restores), nil
+	}
+	return "", nil
+}
+
+// writeBlockCheck refuses to restart a cluster that has write blocks
+// in place. read_only_allow_delete on tables almost always means a
+// disk watermark was breached; restarting nodes then adds recovery
+// traffic to already-full disks and usually makes the situation worse.
+// Disabled shard allocation would leave every restarted node's shards
+// unassigned.
+func writeBlockCheck(ctx context.Context, sql *cratesql.Client) error {
+	res, err := sql.Exec(ctx,
+		"SELECT table_schema, table_name FROM information_schema.tables WHERE settings['blocks']['read_only_allow_delete'] AND table_schema NOT IN ('sys', 'information_schema', 'pg_catalog')")
+	if err != nil {
+		return fmt.Errorf("write-block check: %w", err)
+	}
+	var blocked []string
+	for _, row := range res.Rows {
+		schema, _ := row[0].(string)
+		table, _ := row[1].(string)
+		blocked = append(blocked, schema+"."+table)
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("write-block check: table(s) are read_only_allow_delete (usually a breached disk watermark): %s; free disk space before restarting",
+			strings.Join(blocked, ", "))
+	}
+	res, err = sql.Exec(ctx, "SELECT settings['cluster']['routing']['allocation']['enable'] FROM sys.cluster")
+	if err != nil {
+		return fmt.Errorf("write-block check: %w", err)
+	}
+	if len(res.Rows) > 0 {
+		if mode, _ := res.Rows[0][0].(string); mode != "" && mode != "all" {
+			return fmt.Errorf("write-block check: shard allocation is %q; restarted nodes would not get their shards back", mode)
+		}
+	}
+	return nil
+}
+
+// quorumPreflight verifies the cluster can lose one node right now
+// without losing master quorum or the last copy of any table's data,
+// and explains what blocks the restart when it cannot. Zero-replica
+// tables only make data temporarily unavailable rather than corrupt
+// it, so that finding can be acknowledged away with ackDataLoss.
+func quorumPreflight(ctx context.Context, c *kube.Client, cl cratedb.Cluster, ackDataLoss bool) error {
+	sql, err := cratesql.Connect(ctx, c, cl.Namespace, cl.Name)
+	if err != nil {
+		return fmt.Errorf("quorum preflight: %w", err)
+	}
+	defer sql.Close()
+
+	expected := cl.DataNodes()
+	if cl.Spec.Nodes.Master != nil {
+		expected += cl.Spec.Nodes.Master.Replicas
+	}
+	res, err := sql.Exec(ctx, "SELECT count(*) FROM sys.nodes")
+	if err != nil {
+		return fmt.Errorf("quorum preflight: %w", err)
+	}
+	var present int64
+	if len(res.Rows) > 0 {
+		if f, ok := res.Rows[0][0].(float64); ok {
+			present = int64(f)
+		}
+	}
+	if present < expected {
+		return fmt.Errorf("quorum preflight: only %d of %d nodes joined; taking another down risks quorum", present, expected)
+	}
+	if expected == 2 {
+		return fmt.Errorf("quorum preflight: a 2-node cluster cannot lose a node without losing master quorum")
+	}
+
+	// Tables whose minimum replica count is zero have exactly one copy
+	// of some shards; restarting the node holding them makes that data
+	// unavailable until the node returns.
+	res, err = sql.Exec(ctx,
+		"SELECT table_schema, table_name FROM information_schema.tables WHERE number_of_replicas LIKE '0%' AND table_schema NOT IN ('sys', 'information_schema', 'pg_catalog')")
+	if err != nil {
+		return fmt.Errorf("quorum preflight: %w", err)
+	}
+	var blocking []string
+	for _, row := range res.Rows {
+		schema, _ := row[0].(string)
+		table, _ := row[1].(string)
+		blocking = append(blocking, schema+"."+table)
+	}
+	if len(blocking) > 0 {
+		if !ackDataLoss {
+			return fmt.Errorf("quorum preflight: table(s) with zero minimum replicas would lose availability: %s (pass -acknowledge-data-loss-risk to proceed anyway)",
+				strings.Join(blocking, ", "))
+		}
+		cli.Warnf("%s/%s: proceeding despite zero-replica table(s): %s",
+			cl.Namespace, cl.Name, strings.Join(blocking, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Preemption awareness: when capacity is tight, scheduling a deleted
+// pod's replacement goes through the scheduler's preemption pass —
+// lower-priority pods become victims, higher-priority workloads starve
+// the replacement instead. The check below approximates that pass from
+// spec.priority; it does not model pod anti-affinity or PDBs, which is
+// enough to warn and to refuse the clearly dangerous case.
+
+// checkPreemption surfaces the pod's priority class and, when no node
+// has free capacity for its replacement, who would preempt whom. It
+// errors when recreating the pod could preempt critical system pods.
+func (cp *Campaign) checkPreemption(ctx context.Context, c *kube.Client, t Target, pod map[string]any) error {
+	name := unstructured.NestedString(pod, "metadata", "name")
+	class := unstructured.NestedString(pod, "spec", "priorityClassName")
+	if class == "" {
+		class = "(none)"
+	}
+	priority := unstructured.NestedInt64(pod, "spec", "priority")
+	cli.Infof("%s: pod %s priorityClassName=%s priority=%d", t, name, class, priority)
+	cp.explainf("priority", t, "pod", name, "class", class, "priority", priority)
+
+	ok, _, err := capacityFor(ctx, c, pod)
+	if err != nil {
+		return fmt.Errorf("preemption check: %w", err)
+	}
+	if ok {
+		return nil // a free slot exists; nobody gets preempted
+	}
+
+	allPods, err := c.List(ctx, kube.ResourcePath("v1", "", "pods", ""), nil)
+	if err != nil {
+		return fmt.Errorf("preemption check: %w", err)
+	}
+	selfNS := unstructured.NestedString(pod, "metadata", "namespace")
+	var victims, critical []string
+	higher := 0
+	for _, p := range allPods {
+		phase := unstructured.NestedString(p, "status", "phase")
+		if phase == "Succeeded" || phase == "Failed" ||
+			unstructured.NestedString(p, "spec", "nodeName") == "" {
+			continue
+		}
+		ns := unstructured.NestedString(p, "metadata", "namespace")
+		pname := unstructured.NestedString(p, "metadata", "name")
+		if ns == selfNS && pname == name {
+			continue
+		}
+		switch other := unstructured.NestedInt64(p, "spec", "priority"); {
+		case other < priority:
+			victims = append(victims, ns+"/"+pname)
+			if isCriticalPod(p) {
+				critical = append(critical, ns+"/"+pname)
+			}
+		case other > priority:
+			higher++
+		}
+	}
+	if len(critical) > 0 {
+		if len(critical) > 3 {
+			critical = append(critical[:3], "…")
+		}
+		return fmt.Errorf("preemption check: no free capacity, and recreating %s (priority %d) could preempt critical system pod(s) %s",
+			name, priority, strings.Join(critical, ", "))
+	}
+	if len(victims) > 0 {
+		cli.Warnf("%s: no free capacity; recreating %s may preempt %d lower-priority pod(s)", t, name, len(victims))
+	}
+	if higher > 0 {
+		cli.Warnf("%s: %d higher-priority pod(s) compete for capacity; the replacement for %s could be queued or preempted",
+			t, higher, name)
+	}
+	return nil
+}
+
+// isCriticalPod marks pods whose loss hurts the whole node or cluster:
+// the system- priority classes and everything in kube-system.
+func isCriticalPod(pod map[string]any) bool {
+	if strings.HasPrefix(unstructured.NestedString(pod, "spec", "priorityClassName"), "system-") {
+		return true
+	}
+	return unstructured.NestedString(pod, "metadata", "namespace") == "kube-system"
+}
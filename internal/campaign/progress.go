@@ -0,0 +1,125 @@
+package campaign
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+// progress aggregates the campaign's position for the in-place
+// terminal line: which cluster and pod are being worked on and a rough
+// finish estimate. The per-pod estimate starts from historical
+// time-to-GREEN (Options.PodEstimate) and shifts to the average
+// observed in this campaign as pods complete. With -parallel the line
+// shows the most recently touched cluster; the counters stay accurate.
+type progress struct {
+	mu       sync.Mutex
+	estimate time.Duration
+
+	clusters     int
+	clustersDone int
+	podCounts    []int // pod counts of clusters seen so far, for the ETA
+
+	cluster string // cluster currently rendered
+	pod     int
+	pods    int
+
+	done     int           // pods finished in this campaign
+	observed time.Duration // their summed restart time
+}
+
+func newProgress(clusters int, estimate time.Duration) *progress {
+	return &progress{clusters: clusters, estimate: estimate}
+}
+
+func (p *progress) startCluster(t Target, pods int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cluster, p.pod, p.pods = t.String(), 0, pods
+	p.podCounts = append(p.podCounts, pods)
+	p.render()
+}
+
+func (p *progress) startPod(t Target, i, n int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cluster, p.pod, p.pods = t.String(), i, n
+	p.render()
+}
+
+func (p *progress) podDone(d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.observed += d
+	p.render()
+}
+
+func (p *progress) clusterDone(t Target) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clustersDone++
+	if p.cluster == t.String() {
+		p.cluster, p.pod, p.pods = "", 0, 0
+	}
+	p.render()
+}
+
+// render repaints the progress line; p.mu must be held.
+func (p *progress) render() {
+	current := p.clustersDone
+	if current < p.clusters {
+		current++
+	}
+	line := fmt.Sprintf("cluster %d/%d", current, p.clusters)
+	if p.cluster != "" {
+		line += " " + p.cluster
+	}
+	if p.pods > 0 {
+		line += fmt.Sprintf("  pod %d/%d", p.pod, p.pods)
+	}
+	if eta := p.eta(); eta > 0 {
+		line += fmt.Sprintf("  ETA ~%s", eta.Round(time.Second))
+	}
+	cli.Progress("%s", line)
+}
+
+// eta estimates the remaining duration: pods left in the clusters seen
+// so far plus the average pod count for clusters not yet sized, times
+// the per-pod estimate. Zero when no estimate is available yet.
+func (p *progress) eta() time.Duration {
+	per := p.estimate
+	if p.done > 0 {
+		per = p.observed / time.Duration(p.done)
+	}
+	if per <= 0 {
+		return 0
+	}
+	var sized, sizedPods int
+	for _, n := range p.podCounts {
+		sized++
+		sizedPods += n
+	}
+	remaining := sizedPods - p.done
+	if unsized := p.clusters - sized; unsized > 0 && sized > 0 {
+		remaining += unsized * sizedPods / sized
+	}
+	if remaining <= 0 {
+		return 0
+	}
+	return per * time.Duration(remaining)
+}
@@ -0,0 +1,53 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+)
+
+// recoveryValue limits what we inline into SET GLOBAL: a byte size like
+// 200mb, or a plain shard count is not valid here. CrateDB does not
+// accept parameters in SET statements, so the value must be vetted.
+var recoveryValue = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(b|kb|mb|gb|tb)$`)
+
+// raiseRecoveryLimit raises indices.recovery.max_bytes_per_sec to value
+// for the duration of a cluster's restart and returns a function that
+// restores the previous setting. The change is transient, so a full
+// cluster restart would shed it even if the restore never runs.
+func raiseRecoveryLimit(ctx context.Context, sql *cratesql.Client, target Target, value string) (func(context.Context), error) {
+	if !recoveryValue.MatchString(value) {
+		return nil, fmt.Errorf("invalid recovery rate %q (want a byte size like 200mb)", value)
+	}
+	res, err := sql.Exec(ctx, "SELECT settings['indices']['recovery']['max_bytes_per_sec'] FROM sys.cluster")
+	if err != nil {
+		return nil, fmt.Errorf("reading recovery rate: %w", err)
+	}
+	prev := ""
+	if len(res.Rows) > 0 {
+		prev, _ = res.Rows[0][0].(string)
+	}
+	stmt := fmt.Sprintf(`SET GLOBAL TRANSIENT "indices.recovery.max_bytes_per_sec" = '%s'`, value)
+	if _, err := sql.Exec(ctx, stmt); err != nil {
+		return nil, fmt.Errorf("raising recovery rate: %w", err)
+	}
+	cli.Infof("%s: indices.recovery.max_bytes_per_sec raised %s -> %s for this restart", target, prev, value)
+
+	restore := func(ctx context.Context) {
+		var err error
+		if prev == "" {
+			_, err = sql.Exec(ctx, `RESET GLOBAL "indices.recovery.max_bytes_per_sec"`)
+		} else {
+			_, err = sql.Exec(ctx, fmt.Sprintf(`SET GLOBAL TRANSIENT "indices.recovery.max_bytes_per_sec" = '%s'`, prev))
+		}
+		if err != nil {
+			cli.Warnf("%s: restoring indices.recovery.max_bytes_per_sec: %v", target, err)
+			return
+		}
+		cli.Infof("%s: indices.recovery.max_bytes_per_sec restored", target)
+	}
+	return restore, nil
+}
@@ -0,0 +1,52 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// verifyRevisions checks that every pod of the cluster runs its
+// StatefulSet's updateRevision. After a campaign triggered by spec
+// changes, health-GREEN alone can hide a pod the controller never got
+// to; a pod still on the old revision is a failure, not a success.
+func verifyRevisions(ctx context.Context, c *kube.Client, cl cratedb.Cluster) error {
+	stsIndex, err := cratedb.StatefulSets(ctx, c, []cratedb.Cluster{cl})
+	if err != nil {
+		return fmt.Errorf("verifying revisions: %w", err)
+	}
+	revisions := map[string]string{} // StatefulSet name -> updateRevision
+	for _, sts := range stsIndex[cl.Namespace+"/"+cl.Name] {
+		name := unstructured.NestedString(sts, "metadata", "name")
+		if rev := unstructured.NestedString(sts, "status", "updateRevision"); rev != "" {
+			revisions[name] = rev
+		}
+	}
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return fmt.Errorf("verifying revisions: %w", err)
+	}
+	var stale []string
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		idx := strings.LastIndex(name, "-")
+		if idx < 0 {
+			continue
+		}
+		want, known := revisions[name[:idx]]
+		if !known {
+			continue
+		}
+		if got := unstructured.NestedString(pod, "metadata", "labels", "controller-revision-hash"); got != want {
+			stale = append(stale, fmt.Sprintf("%s (%s, want %s)", name, got, want))
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("pod(s) still on an old StatefulSet revision: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
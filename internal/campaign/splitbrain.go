@@ -0,0 +1,69 @@
+package campaign
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// masterConsensus asks every Ready pod for its own view of the
+// elected master and fails when they disagree or a node sees none.
+// The cluster-level health can look fine from the node the service
+// happens to route to while a partition has the rest following a
+// different master — restarting pods during that state is
+// catastrophic, so the campaign must not proceed.
+func masterConsensus(ctx context.Context, c *kube.Client, cl cratedb.Cluster) error {
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	views := map[string][]string{} // master node id -> pods holding that view
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		if !cratedb.PodReady(pod) {
+			continue // a pod mid-restart has no view worth polling
+		}
+		master, err := masterSeenBy(ctx, c, cl, name)
+		if err != nil {
+			return fmt.Errorf("pod %s: %w", name, err)
+		}
+		if master == "" {
+			return fmt.Errorf("pod %s reports no elected master", name)
+		}
+		views[master] = append(views[master], name)
+	}
+	if len(views) > 1 {
+		var parts []string
+		for master, names := range views {
+			sort.Strings(names)
+			parts = append(parts, fmt.Sprintf("%s seen by %s", master, strings.Join(names, ", ")))
+		}
+		sort.Strings(parts)
+		return fmt.Errorf("nodes disagree about the elected master: %s", strings.Join(parts, "; "))
+	}
+	return nil
+}
+
+// masterSeenBy returns the master node id as one pod sees it.
+func masterSeenBy(ctx context.Context, c *kube.Client, cl cratedb.Cluster, pod string) (string, error) {
+	sql, err := cratesql.ConnectPod(ctx, c, cl.Namespace, cl.Name, pod)
+	if err != nil {
+		return "", err
+	}
+	defer sql.Close()
+	res, err := sql.Exec(ctx, "SELECT master_node FROM sys.cluster")
+	if err != nil {
+		return "", err
+	}
+	if len(res.Rows) == 0 {
+		return "", nil
+	}
+	master, _ := res.Rows[0][0].(string)
+	return master, nil
+}
@@ -0,0 +1,96 @@
+package campaign
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventStream writes one NDJSON event per campaign action to a
+// destination separate from the human log, so workflow engines can
+// react to progress in real time instead of scraping stderr. Events
+// are best-effort: a broken consumer never stops a running campaign.
+type EventStream struct {
+	mu  sync.Mutex
+	w   interface{ Write([]byte) (int, error) }
+	c   interface{ Close() error }
+	err bool // a write failed; stay quiet from then on
+}
+
+// StreamEvent is one entry of the event stream. Type is one of
+// campaign-started, cluster-started, pod-deleted, health-green,
+// cluster-done, campaign-done, or error.
+type StreamEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Campaign string    `json:"campaign"`
+	Cluster  string    `json:"cluster,omitempty"`
+	Pod      string    `json:"pod,omitempty"`
+	Clusters int       `json:"clusters,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// OpenEventStream opens dest for event delivery: "fd:N" writes to an
+// inherited file descriptor (e.g. 3>&1 in a Jenkins step), "unix:PATH"
+// connects to a listening unix socket, and anything else is treated as
+// a file path — a FIFO works well for live consumers.
+func OpenEventStream(dest string) (*EventStream, error) {
+	switch {
+	case strings.HasPrefix(dest, "fd:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(dest, "fd:"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid event destination %q (want fd:N)", dest)
+		}
+		f := os.NewFile(uintptr(n), dest)
+		if f == nil {
+			return nil, fmt.Errorf("file descriptor %d is not open", n)
+		}
+		return &EventStream{w: f, c: f}, nil
+	case strings.HasPrefix(dest, "unix:"):
+		conn, err := net.Dial("unix", strings.TrimPrefix(dest, "unix:"))
+		if err != nil {
+			return nil, fmt.Errorf("connecting event stream: %w", err)
+		}
+		return &EventStream{w: conn, c: conn}, nil
+	default:
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("opening event stream: %w", err)
+		}
+		return &EventStream{w: f, c: f}, nil
+	}
+}
+
+// Close flushes and closes the destination.
+func (s *EventStream) Close() error {
+	if s == nil || s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// emit writes one event; nil streams and write errors are ignored.
+func (s *EventStream) emit(ev StreamEvent) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	if _, err := s.w.Write(append(b, '\n')); err != nil {
+		s.err = true
+	}
+}
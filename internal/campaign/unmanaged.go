@@ -0,0 +1,296 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/tracing"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// restartUnmanaged rolls one plain StatefulSet running CrateDB without
+// the operator, for clusters still installed via Helm. There is no CR,
+// so every gate is SQL or HTTP: cluster health comes from sys.health
+// over a pod tunnel and responsiveness from the admin port. Operator
+// conveniences — annotation overrides, tier ordering, CR events, the
+// preflights that assume managed service and secret names — do not
+// apply here.
+func (cp *Campaign) restartUnmanaged(ctx context.Context, c *kube.Client, t Target) ClusterResult {
+	res := ClusterResult{Namespace: t.Namespace, Name: t.Name, Started: time.Now()}
+	opts := cp.Options
+	clusterSpan := cp.Tracer.Start(cp.span, "cluster",
+		tracing.String("statefulset.namespace", t.Namespace),
+		tracing.String("statefulset.name", t.Name))
+	defer func() {
+		cp.Stream.emit(StreamEvent{Type: "cluster-done", Campaign: cp.ID,
+			Cluster: t.String(), Status: res.Status, Error: res.Error})
+	}()
+	fail := func(err error) ClusterResult {
+		res.Status = "failed"
+		res.Error = err.Error()
+		res.Finished = time.Now()
+		clusterSpan.End(err)
+		cp.Stream.emit(StreamEvent{Type: "error", Campaign: cp.ID, Cluster: t.String(), Error: err.Error()})
+		return res
+	}
+
+	var sts map[string]any
+	if err := c.Get(ctx, kube.ResourcePath("apps/v1", t.Namespace, "statefulsets", t.Name), &sts); err != nil {
+		return fail(err)
+	}
+	selector := cratedb.StatefulSetSelector(sts)
+	if selector == "" {
+		return fail(fmt.Errorf("statefulset has no spec.selector.matchLabels"))
+	}
+	pods, err := c.List(ctx, kube.ResourcePath("v1", t.Namespace, "pods", ""),
+		url.Values{"labelSelector": []string{selector}})
+	if err != nil {
+		return fail(err)
+	}
+	if len(pods) == 0 {
+		return fail(fmt.Errorf("no pods behind statefulset %s", t.Name))
+	}
+	if len(opts.Pods) > 0 {
+		pods = filterPods(pods, opts.Pods)
+		if len(pods) == 0 {
+			return fail(fmt.Errorf("no pods match -pods=%s", strings.Join(opts.Pods, ",")))
+		}
+	}
+	if len(opts.SkipPods) > 0 {
+		var skipped []string
+		pods, skipped = excludePods(pods, opts.SkipPods)
+		if len(skipped) > 0 {
+			cli.Infof("%s: leaving %s alone on request", t, strings.Join(skipped, ", "))
+		}
+		if len(pods) == 0 {
+			return fail(fmt.Errorf("-skip-pods excludes every pod of the cluster"))
+		}
+	}
+	// Highest ordinal first, mirroring the StatefulSet's own update
+	// order; without the operator's naming there are no pools to group.
+	sort.Slice(pods, func(i, j int) bool {
+		return cratedb.PodOrdinal(pods[i]) > cratedb.PodOrdinal(pods[j])
+	})
+	cp.prog.startCluster(t, len(pods))
+	defer cp.prog.clusterDone(t)
+	cp.Stream.emit(StreamEvent{Type: "cluster-started", Campaign: cp.ID, Cluster: t.String()})
+
+	if !opts.DryRun {
+		h, err := unmanagedHealth(ctx, c, t, selector)
+		if err != nil {
+			return fail(fmt.Errorf("reading sys.health: %w", err))
+		}
+		if !cratedb.HealthAtLeast(h, opts.MinHealth) && !opts.Force {
+			cp.explainf("refuse", t, "health", h, "min_health", opts.MinHealth, "force", opts.Force)
+			return fail(fmt.Errorf("health is %s, below %s (use -force to override)", h, opts.MinHealth))
+		}
+	}
+	if err := cp.awaitApproval(ctx, c, t, len(pods)); err != nil {
+		return fail(err)
+	}
+	cli.Infof("%s: restarting %d pod(s) of unmanaged statefulset", t, len(pods))
+
+	for i := range pods {
+		switch cp.Control.Checkpoint(ctx) {
+		case ControlAbort:
+			res.Status = "skipped"
+			res.Error = "aborted by operator"
+			res.Finished = time.Now()
+			clusterSpan.End(nil)
+			return res
+		case ControlSkip:
+			cli.Infof("%s: skipping remaining pods on operator request", t)
+			res.Status = "skipped"
+			res.Error = "skipped by operator"
+			res.Finished = time.Now()
+			clusterSpan.End(nil)
+			return res
+		}
+		name := unstructured.NestedString(pods[i], "metadata", "name")
+		cp.prog.startPod(t, i+1, len(pods))
+		cp.explainf("restart-pod", t,
+			"pod", name, "position", fmt.Sprintf("%d/%d", i+1, len(pods)),
+			"min_health", opts.MinHealth, "unmanaged", true)
+		if opts.CapacityCheck && !opts.DryRun {
+			if err := cp.awaitCapacity(ctx, c, t, pods[i], opts); err != nil {
+				return fail(fmt.Errorf("before pod %s: %w", name, err))
+			}
+		}
+		if opts.PreemptionCheck && !opts.DryRun {
+			if err := cp.checkPreemption(ctx, c, t, pods[i]); err != nil {
+				return fail(fmt.Errorf("before pod %s: %w", name, err))
+			}
+		}
+		pr := PodResult{Pod: name, Started: time.Now()}
+		err := cp.restartUnmanagedPod(ctx, c, t, name, selector, clusterSpan, opts)
+		pr.Finished = time.Now()
+		if err != nil {
+			pr.Error = err.Error()
+			res.Pods = append(res.Pods, pr)
+			if errors.Is(err, ErrHealthTimeout) && opts.OnTimeout == TimeoutSkip {
+				cli.Warnf("%s: pod %s: %v; skipping the rest of this cluster", t, name, err)
+				res.Status = "skipped"
+				res.Error = err.Error()
+				res.Finished = time.Now()
+				clusterSpan.End(err)
+				return res
+			}
+			return fail(fmt.Errorf("pod %s: %w", name, err))
+		}
+		res.Pods = append(res.Pods, pr)
+		cp.prog.podDone(pr.Finished.Sub(pr.Started))
+		cli.Infof("%s: pod %s back in %s", t, name, pr.Finished.Sub(pr.Started).Round(time.Second))
+		if i < len(pods)-1 && !opts.DryRun {
+			pause := opts.PodPause
+			if adaptive := time.Duration(opts.AdaptivePace * float64(pr.Finished.Sub(pr.Started))); adaptive > pause {
+				pause = adaptive
+			}
+			if pause > 0 {
+				cli.Infof("%s: waiting %s before the next pod", t, pause.Round(time.Second))
+				if err := cli.Sleep(ctx, pause); err != nil {
+					return fail(err)
+				}
+			}
+		}
+	}
+	res.Status = "succeeded"
+	res.Finished = time.Now()
+	clusterSpan.End(nil)
+	return res
+}
+
+// restartUnmanagedPod is the unmanaged counterpart of restartPod: same
+// delete-and-wait mechanics, but the post-restart gates are the admin
+// port probe and sys.health instead of the CR status.
+func (cp *Campaign) restartUnmanagedPod(ctx context.Context, c *kube.Client, t Target, pod, selector string, clusterSpan *tracing.Span, opts Options) (err error) {
+	span := cp.Tracer.Start(clusterSpan, "pod", tracing.String("pod.name", pod))
+	defer func() { span.End(err) }()
+	if opts.DryRun {
+		cli.Infof("%s: would delete pod %s", t, pod)
+		return nil
+	}
+	cli.Infof("%s: deleting pod %s", t, pod)
+	path := kube.ResourcePath("v1", t.Namespace, "pods", pod)
+	var uid string
+	var existing map[string]any
+	if err := c.Get(ctx, path, &existing); err == nil {
+		uid = unstructured.NestedString(existing, "metadata", "uid")
+	}
+	if err := c.Delete(ctx, path); err != nil {
+		return fmt.Errorf("deleting: %w", err)
+	}
+	cp.Stream.emit(StreamEvent{Type: "pod-deleted", Campaign: cp.ID, Cluster: t.String(), Pod: pod})
+	if err := cp.waitPodReady(ctx, c, path, uid, opts); err != nil {
+		return err
+	}
+	// The admin-port probe is not optional here: with no operator
+	// watching the node, it is the only process-level liveness signal.
+	if err := probeHTTP(ctx, c, t, pod, opts); err != nil {
+		return err
+	}
+	if err := cp.waitUnmanagedHealthy(ctx, c, t, selector, opts); err != nil {
+		return err
+	}
+	cp.Stream.emit(StreamEvent{Type: "health-green", Campaign: cp.ID,
+		Cluster: t.String(), Pod: pod, Status: opts.MinHealth})
+	if opts.PostReadySoak > 0 {
+		cli.Infof("%s: pod %s Ready; soaking for %s", t, pod, opts.PostReadySoak)
+		if err := cli.Sleep(ctx, opts.PostReadySoak); err != nil {
+			return err
+		}
+		var p map[string]any
+		if err := c.Get(ctx, path, &p); err != nil {
+			return fmt.Errorf("pod disappeared during soak: %w", err)
+		}
+		if !cratedb.PodReady(p) {
+			return fmt.Errorf("pod %s fell out of Ready during the %s soak", pod, opts.PostReadySoak)
+		}
+		h, err := unmanagedHealth(ctx, c, t, selector)
+		if err != nil {
+			return err
+		}
+		if !cratedb.HealthAtLeast(h, opts.MinHealth) {
+			return fmt.Errorf("health fell to %s during the %s soak", h, opts.PostReadySoak)
+		}
+	}
+	return nil
+}
+
+// waitUnmanagedHealthy polls sys.health until it reports at least
+// MinHealth. With no CR status to watch this is plain polling over a
+// pod tunnel, at a gentler cadence than the managed watch since every
+// sample pays for a fresh port-forward.
+func (cp *Campaign) waitUnmanagedHealthy(ctx context.Context, c *kube.Client, t Target, selector string, opts Options) error {
+	deadline := time.Now().Add(opts.HealthTimeout)
+	poll := opts.Poll
+	if poll < 15*time.Second {
+		poll = 15 * time.Second
+	}
+	for {
+		h, err := unmanagedHealth(ctx, c, t, selector)
+		if err != nil {
+			cli.Debugf("%s: %v", t, err)
+		} else if cratedb.HealthAtLeast(h, opts.MinHealth) {
+			return nil
+		} else {
+			cli.Debugf("%s: health %s, polling", t, h)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (%s) after %s", ErrHealthTimeout, opts.MinHealth, opts.HealthTimeout)
+		}
+		if err := cli.Sleep(ctx, poll); err != nil {
+			return err
+		}
+	}
+}
+
+// unmanagedHealth reads cluster health from sys.health through the
+// first Ready pod that answers. Severities map onto the operator's
+// health vocabulary so the usual -min-health gates apply unchanged;
+// no reachable pod reads as UNREACHABLE rather than an error.
+func unmanagedHealth(ctx context.Context, c *kube.Client, t Target, selector string) (string, error) {
+	pods, err := c.List(ctx, kube.ResourcePath("v1", t.Namespace, "pods", ""),
+		url.Values{"labelSelector": []string{selector}})
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if !cratedb.PodReady(pod) {
+			continue
+		}
+		name := unstructured.NestedString(pod, "metadata", "name")
+		sql, err := cratesql.ConnectPodTrust(ctx, c, t.Namespace, name)
+		if err != nil {
+			cli.Debugf("%s: pod %s: %v", t, name, err)
+			continue
+		}
+		res, err := sql.Exec(ctx, "SELECT coalesce(max(severity), 1) FROM sys.health")
+		sql.Close()
+		if err != nil {
+			cli.Debugf("%s: pod %s: %v", t, name, err)
+			continue
+		}
+		sev := float64(1)
+		if len(res.Rows) > 0 {
+			sev, _ = res.Rows[0][0].(float64)
+		}
+		switch int(sev) {
+		case 1:
+			return cratedb.HealthGreen, nil
+		case 2:
+			return cratedb.HealthYellow, nil
+		default:
+			return cratedb.HealthRed, nil
+		}
+	}
+	return cratedb.HealthUnreachable, nil
+}
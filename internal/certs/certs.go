@@ -0,0 +1,113 @@
+// Package certs inspects the TLS secrets belonging to CrateDB
+// clusters: certificate expiry for alerting and a content hash for
+// detecting rotations.
+package certs
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Info describes one TLS secret of a cluster.
+type Info struct {
+	Secret   string
+	Subject  string
+	NotAfter time.Time
+	Hash     string // sha256 over the secret's data, for change detection
+}
+
+// ForCluster returns cert info for every kubernetes.io/tls secret
+// labeled as belonging to the cluster.
+func ForCluster(ctx context.Context, k *kube.Client, cl cratedb.Cluster) ([]Info, error) {
+	q := url.Values{
+		"labelSelector": []string{"app.kubernetes.io/name=" + cl.Name},
+		"fieldSelector": []string{"type=kubernetes.io/tls"},
+	}
+	secrets, err := k.List(ctx, kube.ResourcePath("v1", cl.Namespace, "secrets", ""), q)
+	if err != nil {
+		return nil, fmt.Errorf("listing TLS secrets for %s/%s: %w", cl.Namespace, cl.Name, err)
+	}
+	infos := make([]Info, 0, len(secrets))
+	for _, s := range secrets {
+		info := Info{
+			Secret: unstructured.NestedString(s, "metadata", "name"),
+			Hash:   HashSecretData(s),
+		}
+		if enc := unstructured.NestedString(s, "data", "tls.crt"); enc != "" {
+			if raw, err := base64.StdEncoding.DecodeString(enc); err == nil {
+				if cert := parseLeaf(raw); cert != nil {
+					info.Subject = cert.Subject.CommonName
+					info.NotAfter = cert.NotAfter
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Secret < infos[j].Secret })
+	return infos, nil
+}
+
+// SoonestExpiry returns the earliest NotAfter across infos, or zero
+// when no certificate could be parsed.
+func SoonestExpiry(infos []Info) time.Time {
+	var soonest time.Time
+	for _, in := range infos {
+		if in.NotAfter.IsZero() {
+			continue
+		}
+		if soonest.IsZero() || in.NotAfter.Before(soonest) {
+			soonest = in.NotAfter
+		}
+	}
+	return soonest
+}
+
+// HashSecretData hashes a secret's data map deterministically.
+func HashSecretData(secret map[string]any) string {
+	data := unstructured.NestedMap(secret, "data")
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		if s, ok := data[k].(string); ok {
+			h.Write([]byte(s))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func parseLeaf(pemBytes []byte) *x509.Certificate {
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if !cert.IsCA {
+			return cert
+		}
+	}
+}
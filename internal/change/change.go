@@ -0,0 +1,149 @@
+// Package change files change records with external change-management
+// systems (Jira, ServiceNow) around restart campaigns: a record is
+// created before the first pod is touched and the final report is
+// appended as a comment when the campaign finishes.
+package change
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// System is one change-management backend.
+type System interface {
+	// Create opens a change record and returns its identifier.
+	Create(ctx context.Context, summary, description string) (string, error)
+	// Comment appends a comment or work note to an existing record.
+	Comment(ctx context.Context, id, body string) error
+}
+
+// New builds the system named by name ("jira" or "servicenow") against
+// baseURL. Credentials come from the environment: $JIRA_USER and
+// $JIRA_TOKEN, or $SNOW_USER and $SNOW_PASSWORD.
+func New(name, baseURL, project string) (System, error) {
+	base := strings.TrimSuffix(baseURL, "/")
+	if base == "" {
+		return nil, fmt.Errorf("-change-system needs -change-url")
+	}
+	hc := &http.Client{Timeout: 30 * time.Second}
+	switch name {
+	case "jira":
+		return &jira{base: base, project: project, user: os.Getenv("JIRA_USER"), token: os.Getenv("JIRA_TOKEN"), http: hc}, nil
+	case "servicenow":
+		return &servicenow{base: base, user: os.Getenv("SNOW_USER"), password: os.Getenv("SNOW_PASSWORD"), http: hc}, nil
+	default:
+		return nil, fmt.Errorf("unknown change system %q (want jira or servicenow)", name)
+	}
+}
+
+type jira struct {
+	base    string
+	project string
+	user    string
+	token   string
+	http    *http.Client
+}
+
+func (j *jira) Create(ctx context.Context, summary, description string) (string, error) {
+	payload := map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]any{"key": j.project},
+			"issuetype":   map[string]any{"name": "Task"},
+			"summary":     summary,
+			"description": description,
+		},
+	}
+	var out struct {
+		Key string `json:"key"`
+	}
+	if err := postJSON(ctx, j.http, j.base+"/rest/api/2/issue", j.user, j.token, payload, &out); err != nil {
+		return "", fmt.Errorf("creating jira issue: %w", err)
+	}
+	return out.Key, nil
+}
+
+func (j *jira) Comment(ctx context.Context, id, body string) error {
+	payload := map[string]any{"body": body}
+	if err := postJSON(ctx, j.http, j.base+"/rest/api/2/issue/"+id+"/comment", j.user, j.token, payload, nil); err != nil {
+		return fmt.Errorf("commenting on %s: %w", id, err)
+	}
+	return nil
+}
+
+type servicenow struct {
+	base     string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func (s *servicenow) Create(ctx context.Context, summary, description string) (string, error) {
+	payload := map[string]any{
+		"short_description": summary,
+		"description":       description,
+	}
+	var out struct {
+		Result struct {
+			SysID string `json:"sys_id"`
+		} `json:"result"`
+	}
+	if err := postJSON(ctx, s.http, s.base+"/api/now/table/change_request", s.user, s.password, payload, &out); err != nil {
+		return "", fmt.Errorf("creating change request: %w", err)
+	}
+	return out.Result.SysID, nil
+}
+
+func (s *servicenow) Comment(ctx context.Context, id, body string) error {
+	payload := map[string]any{"work_notes": body}
+	req, err := jsonRequest(ctx, http.MethodPatch, s.base+"/api/now/table/change_request/"+id, s.user, s.password, payload)
+	if err != nil {
+		return err
+	}
+	if err := doChecked(s.http, req, nil); err != nil {
+		return fmt.Errorf("updating change request %s: %w", id, err)
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, hc *http.Client, url, user, pass string, payload, out any) error {
+	req, err := jsonRequest(ctx, http.MethodPost, url, user, pass, payload)
+	if err != nil {
+		return err
+	}
+	return doChecked(hc, req, out)
+}
+
+func jsonRequest(ctx context.Context, method, url, user, pass string, payload any) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(user, pass)
+	return req, nil
+}
+
+func doChecked(hc *http.Client, req *http.Request, out any) error {
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Package cli holds shared flag and output helpers for the crt
+// subcommands.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// Verbose enables debug output; set from the -v flag.
+var Verbose bool
+
+// Strict makes parse problems in CrateDB objects fatal instead of
+// warnings; set from the -strict flag.
+var Strict bool
+
+// Quiet suppresses informational log lines and the progress line,
+// leaving warnings, errors, and final summaries; set from -quiet.
+var Quiet bool
+
+// Output selects the log encoding: "" for human lines, "ndjson" for
+// one JSON object per log event so CI jobs and log pipelines never see
+// decorative output; set from the -output flag.
+var Output string
+
+// OutputNDJSON is the machine value for -output.
+const OutputNDJSON = "ndjson"
+
+// GlobalFlags are the flags every subcommand accepts.
+type GlobalFlags struct {
+	Context       string
+	Namespace     string
+	AllNamespaces bool
+	Profile       string
+	Kubeconfig    string
+	Offline       string
+	Fixtures      string
+	Record        string
+	ReadOnly      bool
+}
+
+// Register adds the global flags to fs. The kubectl-compatible
+// spellings (-n, -A, --kubeconfig) are included so the binary behaves
+// as expected when installed as a kubectl plugin.
+func (g *GlobalFlags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&g.Context, "context", "", "kube context to use (default: current)")
+	fs.StringVar(&g.Namespace, "namespace", "", "namespace to operate in (default: from context)")
+	fs.StringVar(&g.Namespace, "n", "", "shorthand for -namespace")
+	fs.StringVar(&g.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file to use")
+	fs.BoolVar(&g.AllNamespaces, "all-namespaces", false, "operate across all namespaces")
+	fs.BoolVar(&g.AllNamespaces, "A", false, "shorthand for -all-namespaces")
+	fs.StringVar(&g.Profile, "profile", "", "config profile to apply (see "+config.Path()+")")
+	fs.StringVar(&g.Offline, "offline", "", "serve reads from a `crt snapshot` directory instead of a cluster")
+	fs.StringVar(&g.Fixtures, "fixtures", "", "play back all API interactions from a fixture directory (for demos and tests)")
+	fs.StringVar(&g.Record, "record", "", "capture every API interaction to this file for later `crt replay`")
+	fs.BoolVar(&g.ReadOnly, "read-only", buildReadOnly, "refuse every mutating API call; for inspection-only use")
+	fs.BoolVar(&Quiet, "quiet", false, "suppress informational output; errors and summaries only")
+	fs.StringVar(&Output, "output", "", `log encoding: "" for human lines or ndjson for one JSON object per event`)
+	fs.BoolVar(&Verbose, "v", false, "verbose output")
+	fs.BoolVar(&Strict, "strict", false, "fail on CrateDB objects whose spec or status cannot be parsed")
+}
+
+// EnvPrefix maps flags to environment variables: -health-timeout
+// becomes CRATE_TOOL_HEALTH_TIMEOUT. Useful in CronJobs and CI where
+// templating argv is awkward.
+const EnvPrefix = "CRATE_TOOL_"
+
+// Parse parses args, then fills unset flags from CRATE_TOOL_*
+// environment variables, then from the config file (selected profile
+// and defaults). Precedence is flag > env > config. All subcommands
+// parse through this so the layering works uniformly.
+func Parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyEnv(fs); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	profile := ""
+	if f := fs.Lookup("profile"); f != nil {
+		profile = f.Value.String()
+	}
+	if err := cfg.Apply(fs, profile); err != nil {
+		return err
+	}
+	if Output != "" && Output != OutputNDJSON {
+		return fmt.Errorf("invalid -output %q (want ndjson)", Output)
+	}
+	return nil
+}
+
+// applyEnv sets flags from their CRATE_TOOL_* variables. Flags set on
+// the command line win; flags set here count as set when the config
+// file is applied afterwards, giving env > config.
+func applyEnv(fs *flag.FlagSet) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { explicit[fl.Name] = true })
+	var envErr error
+	fs.VisitAll(func(fl *flag.Flag) {
+		if explicit[fl.Name] || envErr != nil {
+			return
+		}
+		name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(fl.Name, "-", "_"))
+		if value, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(fl.Name, value); err != nil {
+				envErr = fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	})
+	return envErr
+}
+
+// Client builds a kube client from the global flags.
+func (g *GlobalFlags) Client() (*kube.Client, error) {
+	if g.Fixtures != "" {
+		return kube.NewFixtureClient(g.Fixtures)
+	}
+	if g.Offline != "" {
+		return kube.NewOfflineClient(g.Offline)
+	}
+	cfg, err := kube.LoadConfig(g.Context, g.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if g.Namespace != "" {
+		cfg.Namespace = g.Namespace
+	}
+	c, err := kube.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	fileCfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(fileCfg.AllowedContexts) > 0 && cfg.Context != "" {
+		allowed := false
+		for _, ctx := range fileCfg.AllowedContexts {
+			allowed = allowed || ctx == cfg.Context
+		}
+		if !allowed {
+			return nil, fmt.Errorf("kube context %q is outside this binary's allow-list (%s)", cfg.Context, strings.Join(fileCfg.AllowedContexts, ", "))
+		}
+	}
+	if len(fileCfg.AllowedNamespaces) > 0 {
+		c.RestrictNamespaces(fileCfg.AllowedNamespaces)
+	}
+	if g.ReadOnly || buildReadOnly {
+		c.SetReadOnly()
+	}
+	if g.Record != "" {
+		if err := c.Record(g.Record); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ResolveNamespace returns the namespace to scope list operations to:
+// empty for all namespaces, otherwise the flag or context namespace.
+func (g *GlobalFlags) ResolveNamespace(c *kube.Client) string {
+	if g.AllNamespaces {
+		return ""
+	}
+	return c.Namespace()
+}
+
+// Identity resolves who is running the tool, best first: an explicit
+// $CRT_OPERATOR (set by SSO wrappers and CI), the kubeconfig user of
+// the client, then the local OS user. It is stamped into annotations,
+// Events and reports so "who rolled prod at 3am" has an answer.
+func Identity(c *kube.Client) string {
+	if v := os.Getenv("CRT_OPERATOR"); v != "" {
+		return v
+	}
+	if c != nil && c.Config().User != "" {
+		return c.Config().User
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// Confirm asks for interactive confirmation on stdin.
+func Confirm(action string) bool {
+	fmt.Fprintf(os.Stderr, "%s? [y/N] ", action)
+	sc := bufio.NewScanner(os.Stdin)
+	if !sc.Scan() {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(sc.Text()))
+	return answer == "y" || answer == "yes"
+}
+
+// Tag, when set, is included in every log line. Campaigns set it to
+// their ID so log aggregators can stitch one restart story together
+// across tools.
+var Tag string
+
+// ConfirmTyped asks the user to type expected verbatim, for
+// confirmations where a stray "y" must not be enough.
+func ConfirmTyped(prompt, expected string) bool {
+	fmt.Fprintf(os.Stderr, "%s: type %q to confirm: ", prompt, expected)
+	sc := bufio.NewScanner(os.Stdin)
+	if !sc.Scan() {
+		return false
+	}
+	return strings.TrimSpace(sc.Text()) == expected
+}
+
+func stamp() string {
+	s := time.Now().Format("15:04:05")
+	if Tag != "" {
+		s += " [" + Tag + "]"
+	}
+	return s
+}
+
+// Infof logs a progress line to stderr.
+func Infof(format string, args ...any) {
+	if Quiet {
+		return
+	}
+	if Output == OutputNDJSON {
+		logJSON("info", fmt.Sprintf(format, args...))
+		return
+	}
+	logf(fmt.Sprintf("%s  %s", stamp(), fmt.Sprintf(format, args...)))
+}
+
+// Warnf logs a warning line to stderr. Warnings survive -quiet.
+func Warnf(format string, args ...any) {
+	if Output == OutputNDJSON {
+		logJSON("warning", fmt.Sprintf(format, args...))
+		return
+	}
+	logf(fmt.Sprintf("%s  warning: %s", stamp(), fmt.Sprintf(format, args...)))
+}
+
+// Debugf logs only when -v was given.
+func Debugf(format string, args ...any) {
+	if !Verbose || Quiet {
+		return
+	}
+	if Output == OutputNDJSON {
+		logJSON("debug", fmt.Sprintf(format, args...))
+		return
+	}
+	logf(fmt.Sprintf("%s  debug: %s", stamp(), fmt.Sprintf(format, args...)))
+}
+
+// logJSON emits one NDJSON log event on stderr.
+func logJSON(level, msg string) {
+	e := struct {
+		Time     time.Time `json:"time"`
+		Level    string    `json:"level"`
+		Campaign string    `json:"campaign,omitempty"`
+		Msg      string    `json:"msg"`
+	}{time.Now().UTC(), level, Tag, msg}
+	b, _ := json.Marshal(e)
+	logf(string(b))
+}
+
+// Sleep pauses for d or until ctx is done, returning ctx.Err() in the
+// latter case.
+func Sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The progress line is rendered in place at the bottom of the log
+// output and repainted after every log line, so long campaigns show
+// where they are without scrolling. Rendering is skipped entirely when
+// stderr is not a terminal, keeping piped and CronJob output clean.
+var (
+	progressMu   sync.Mutex
+	progressLine string
+	progressTTY  = stderrIsTerminal()
+)
+
+func stderrIsTerminal() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Progress replaces the in-place progress line. Machine and quiet
+// modes never render it.
+func Progress(format string, args ...any) {
+	if !progressTTY || Quiet || Output != "" {
+		return
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	progressLine = fmt.Sprintf(format, args...)
+	fmt.Fprintf(os.Stderr, "\r\x1b[K%s", progressLine)
+}
+
+// ProgressDone clears the progress line.
+func ProgressDone() {
+	if !progressTTY {
+		return
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	progressLine = ""
+	fmt.Fprint(os.Stderr, "\r\x1b[K")
+}
+
+// logf prints one finished log line, lifting the progress line out of
+// the way first and repainting it afterwards.
+func logf(line string) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if progressTTY && progressLine != "" {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+	fmt.Fprintln(os.Stderr, line)
+	if progressTTY && progressLine != "" {
+		fmt.Fprint(os.Stderr, progressLine)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !readonly
+
+package cli
+
+// buildReadOnly reports whether this binary was built with the
+// readonly tag, which forces read-only mode regardless of flags.
+const buildReadOnly = false
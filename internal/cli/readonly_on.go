@@ -0,0 +1,9 @@
+//go:build readonly
+
+package cli
+
+// buildReadOnly reports whether this binary was built with the
+// readonly tag, which forces read-only mode regardless of flags. Build
+// with `go build -tags readonly` to produce an inspection-only binary
+// for on-call responders.
+const buildReadOnly = true
@@ -0,0 +1,130 @@
+// Package config loads the tool's config file. The file defines named
+// profiles whose entries are flag-name/value pairs, so one
+// -profile=prod-eu replaces a long list of flags. The file is JSON
+// (~/.config/crt/config.json) — the tool deliberately carries no YAML
+// dependency.
+//
+//	{
+//	  "defaults": {"namespace": "crate"},
+//	  "profiles": {
+//	    "prod-eu": {"context": "prod-eu", "health-timeout": "45m"}
+//	  }
+//	}
+//
+// Precedence is: explicit flag > profile > defaults.
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// File is the parsed config file.
+type File struct {
+	Defaults map[string]string            `json:"defaults"`
+	Profiles map[string]map[string]string `json:"profiles"`
+
+	// Protected lists namespace/name clusters this tool must never
+	// restart without -break-glass plus typed confirmation.
+	Protected []string `json:"protected"`
+
+	// TenantLabels are the CR label keys identifying the owning team,
+	// checked in order; see TenantKeys for the default.
+	TenantLabels []string `json:"tenant_labels"`
+
+	// TenantWebhooks routes campaign notifications per tenant label
+	// value; the "*" entry catches clusters without a routed tenant.
+	TenantWebhooks map[string]string `json:"tenant_webhooks"`
+
+	// HealthPath and GreenValues adapt the tool to forked or newer
+	// operator CRDs: HealthPath is the dotted path to the health field
+	// (default status.crateDBStatus.health) and GreenValues lists
+	// additional health values treated as GREEN, e.g. OK.
+	HealthPath  string   `json:"health_path"`
+	GreenValues []string `json:"green_values"`
+
+	// AllowedNamespaces and AllowedContexts, when non-empty, confine
+	// the tool to those namespaces and kube contexts no matter what
+	// flags are passed — for shared binaries on bastion hosts.
+	AllowedNamespaces []string `json:"allowed_namespaces"`
+	AllowedContexts   []string `json:"allowed_contexts"`
+}
+
+// Path returns the config file location, honoring CRT_CONFIG.
+func Path() string {
+	if p := os.Getenv("CRT_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "crt", "config.json")
+}
+
+// Load reads the config file; a missing file yields an empty config.
+func Load() (*File, error) {
+	path := Path()
+	if path == "" {
+		return &File{}, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// TenantKeys returns the configured tenant label keys, defaulting to
+// the plain "team" and "owner" labels.
+func (f *File) TenantKeys() []string {
+	if len(f.TenantLabels) > 0 {
+		return f.TenantLabels
+	}
+	return []string{"team", "owner"}
+}
+
+// Apply sets flag values from defaults and the selected profile for
+// every flag the user did not set explicitly.
+func (f *File) Apply(fs *flag.FlagSet, profile string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { explicit[fl.Name] = true })
+
+	apply := func(values map[string]string, source string) error {
+		for name, value := range values {
+			if explicit[name] {
+				continue
+			}
+			if fs.Lookup(name) == nil {
+				continue // profiles may carry flags of other subcommands
+			}
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("%s value for -%s: %w", source, name, err)
+			}
+		}
+		return nil
+	}
+	if err := apply(f.Defaults, "config default"); err != nil {
+		return err
+	}
+	if profile != "" {
+		values, ok := f.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("profile %q not found in %s", profile, Path())
+		}
+		if err := apply(values, "profile "+profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
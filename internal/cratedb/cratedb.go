@@ -0,0 +1,406 @@
+// Package cratedb knows how the crate-operator lays out its CrateDB
+// custom resources and the workloads it creates for them.
+package cratedb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// APIVersion and Plural identify the CrateDB custom resource.
+const (
+	APIVersion = "cloud.crate.io/v1"
+	Plural     = "cratedbs"
+)
+
+// AnnotationPrefix namespaces all annotations this tool reads or
+// writes on CrateDB objects.
+const AnnotationPrefix = "crt.walbeh.dev/"
+
+// Health values reported by the operator in status.crateDBStatus.health.
+const (
+	HealthGreen       = "GREEN"
+	HealthYellow      = "YELLOW"
+	HealthRed         = "RED"
+	HealthUnreachable = "UNREACHABLE"
+)
+
+// healthRank orders health states from worst to best.
+var healthRank = map[string]int{
+	HealthUnreachable: 0,
+	HealthRed:         1,
+	HealthYellow:      2,
+	HealthGreen:       3,
+}
+
+// KnownHealth reports whether h is one of the operator's health values.
+func KnownHealth(h string) bool {
+	_, ok := healthRank[h]
+	return ok
+}
+
+// HealthRank returns the numeric rank of a health value, 0
+// (UNREACHABLE) through 3 (GREEN), for metrics and comparisons.
+func HealthRank(h string) int {
+	return healthRank[h]
+}
+
+// HealthAtLeast reports whether h is at least as good as min.
+func HealthAtLeast(h, min string) bool {
+	return healthRank[h] >= healthRank[min]
+}
+
+// Cluster wraps one CrateDB object. Spec and Status are the typed
+// views converted once at load time; Obj keeps the raw object for the
+// few fields the types do not model.
+type Cluster struct {
+	Namespace string
+	Name      string
+	Spec      Spec
+	Status    Status
+	Obj       map[string]any
+}
+
+// List returns the CrateDB clusters in namespace, or across all
+// namespaces when namespace is empty.
+func List(ctx context.Context, c *kube.Client, namespace string) ([]Cluster, error) {
+	items, err := c.List(ctx, kube.ResourcePath(APIVersion, namespace, Plural, ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing cratedbs: %w", err)
+	}
+	clusters := make([]Cluster, 0, len(items))
+	for _, it := range items {
+		cl, err := wrap(it)
+		if err != nil {
+			if cli.Strict {
+				return nil, fmt.Errorf("cratedb %s/%s: %w", cl.Namespace, cl.Name, err)
+			}
+			cli.Warnf("cratedb %s/%s has an unexpected shape: %v", cl.Namespace, cl.Name, err)
+		}
+		clusters = append(clusters, cl)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Namespace != clusters[j].Namespace {
+			return clusters[i].Namespace < clusters[j].Namespace
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters, nil
+}
+
+// Get fetches a single CrateDB cluster.
+func Get(ctx context.Context, c *kube.Client, namespace, name string) (Cluster, error) {
+	var obj map[string]any
+	err := c.Get(ctx, kube.ResourcePath(APIVersion, namespace, Plural, name), &obj)
+	if err != nil {
+		return Cluster{}, fmt.Errorf("getting cratedb %s/%s: %w", namespace, name, err)
+	}
+	cl, err := wrap(obj)
+	if err != nil {
+		if cli.Strict {
+			return Cluster{}, fmt.Errorf("cratedb %s/%s: %w", namespace, name, err)
+		}
+		cli.Warnf("cratedb %s/%s has an unexpected shape: %v", namespace, name, err)
+	}
+	return cl, nil
+}
+
+func wrap(obj map[string]any) (Cluster, error) {
+	cl := Cluster{
+		Namespace: unstructured.NestedString(obj, "metadata", "namespace"),
+		Name:      unstructured.NestedString(obj, "metadata", "name"),
+		Obj:       obj,
+	}
+	var errs []error
+	if err := decodeInto(obj["spec"], &cl.Spec); err != nil {
+		errs = append(errs, fmt.Errorf("spec: %w", err))
+	}
+	if err := decodeInto(obj["status"], &cl.Status); err != nil {
+		errs = append(errs, fmt.Errorf("status: %w", err))
+	}
+	return cl, errors.Join(errs...)
+}
+
+// decodeInto converts a JSON map into a typed struct via a
+// marshal/unmarshal round trip — slower than hand-rolled conversion
+// but immune to drift between the two representations.
+func decodeInto(v any, out any) error {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func toString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// healthPath is where HealthOf reads from; greenAlias maps foreign
+// health values onto GREEN. Both are fixed unless SetHealthMapping
+// reconfigures them at startup.
+var (
+	healthPath = []string{"status", "crateDBStatus", "health"}
+	greenAlias = map[string]bool{}
+)
+
+// SetHealthMapping adapts health reading to forked or newer operator
+// CRDs whose status layout differs: path is the dotted path to the
+// health field, rooted at the object (default
+// status.crateDBStatus.health), and greens lists additional values
+// treated as GREEN, e.g. OK. Empty arguments leave the defaults alone.
+func SetHealthMapping(path string, greens []string) {
+	if path != "" {
+		healthPath = strings.Split(path, ".")
+	}
+	for _, v := range greens {
+		if v != "" && v != HealthGreen {
+			greenAlias[v] = true
+		}
+	}
+}
+
+// HealthOf reads the cluster health from a raw CR object, applying the
+// configured path and value mapping; an unpopulated status reads as
+// HealthUnreachable.
+func HealthOf(obj map[string]any) string {
+	h := unstructured.NestedString(obj, healthPath...)
+	switch {
+	case h == "":
+		return HealthUnreachable
+	case greenAlias[h]:
+		return HealthGreen
+	}
+	return h
+}
+
+// Health returns the operator-reported cluster health, or
+// HealthUnreachable when the status has not been populated.
+func (cl Cluster) Health() string {
+	return HealthOf(cl.Obj)
+}
+
+// Annotation returns the value of the tool's own annotation name
+// (without the prefix), or "".
+func (cl Cluster) Annotation(name string) string {
+	return unstructured.NestedString(cl.Obj, "metadata", "annotations", AnnotationPrefix+name)
+}
+
+// Label returns the value of a metadata label, or "".
+func (cl Cluster) Label(key string) string {
+	return unstructured.NestedString(cl.Obj, "metadata", "labels", key)
+}
+
+// Tenant returns the owning team of the cluster: the first non-empty
+// value among the given label keys. Shared clusters tag CRs with
+// team/owner labels; the keys are configurable via the config file.
+func (cl Cluster) Tenant(keys []string) string {
+	for _, key := range keys {
+		if v := cl.Label(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Created returns the CR's creationTimestamp, or the zero time when it
+// is missing or unparsable.
+func (cl Cluster) Created() time.Time {
+	t, _ := time.Parse(time.RFC3339, unstructured.NestedString(cl.Obj, "metadata", "creationTimestamp"))
+	return t
+}
+
+// Version returns the CrateDB version from the spec.
+func (cl Cluster) Version() string {
+	return cl.Spec.Cluster.Version
+}
+
+// DataNodes returns the total replica count across data node groups.
+func (cl Cluster) DataNodes() int64 {
+	var total int64
+	for _, pool := range cl.Spec.Nodes.Data {
+		total += pool.Replicas
+	}
+	return total
+}
+
+// PodSelector is the label selector the operator conventionally puts
+// on crate pods. It is the fallback when the StatefulSet's own
+// spec.selector cannot be read.
+func (cl Cluster) PodSelector() string {
+	return "app.kubernetes.io/name=" + cl.Name + ",app.kubernetes.io/component=cratedb"
+}
+
+// Pods lists the crate pods of the cluster, sorted by ordinal. The
+// label selector is taken from the owning StatefulSet's spec.selector
+// — the authoritative pod membership — so name collisions with other
+// workloads in the namespace cannot leak foreign pods into a restart.
+func Pods(ctx context.Context, c *kube.Client, cl Cluster) ([]map[string]any, error) {
+	selector := cl.PodSelector()
+	if stss, err := StatefulSets(ctx, c, []Cluster{cl}); err == nil {
+		if s := selectorFromStatefulSets(stss[cl.Namespace+"/"+cl.Name]); s != "" {
+			selector = s
+		}
+	}
+	q := url.Values{"labelSelector": []string{selector}}
+	pods, err := c.List(ctx, kube.ResourcePath("v1", cl.Namespace, "pods", ""), q)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods for %s/%s: %w", cl.Namespace, cl.Name, err)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return PodOrdinal(pods[i]) < PodOrdinal(pods[j])
+	})
+	return pods, nil
+}
+
+// selectorFromStatefulSets renders spec.selector.matchLabels of the
+// first StatefulSet as a label selector string.
+func selectorFromStatefulSets(stss []map[string]any) string {
+	for _, sts := range stss {
+		if s := StatefulSetSelector(sts); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// StatefulSetSelector renders a StatefulSet's spec.selector.matchLabels
+// as a label selector string ("" when none are set).
+func StatefulSetSelector(sts map[string]any) string {
+	labels := unstructured.NestedMap(sts, "spec", "selector", "matchLabels")
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, labels[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// IsMasterPod reports whether the pod belongs to a dedicated master
+// node pool, going by the operator's StatefulSet naming convention.
+func IsMasterPod(pod map[string]any) bool {
+	return strings.HasPrefix(unstructured.NestedString(pod, "metadata", "name"), "crate-master-")
+}
+
+// PodPool returns the data pool a pod belongs to ("master" for
+// dedicated master pods), parsed from the operator's pod naming
+// convention crate-data-<pool>-<cluster>-<ordinal>.
+func PodPool(pod map[string]any, clusterName string) string {
+	if IsMasterPod(pod) {
+		return "master"
+	}
+	name := unstructured.NestedString(pod, "metadata", "name")
+	const prefix = "crate-data-"
+	if !strings.HasPrefix(name, prefix) {
+		return ""
+	}
+	rest := name[len(prefix):]
+	if i := strings.LastIndex(rest, "-"+clusterName+"-"); i >= 0 {
+		return rest[:i]
+	}
+	return ""
+}
+
+// RestartOrder arranges pods for a safe rolling restart: data pods
+// first, then dedicated master pods one by one at the end so voting
+// members are disturbed as late and as briefly as possible. Within
+// each pool the highest ordinal goes first, mirroring the
+// StatefulSet's own update order. A non-empty tierOrder restarts the
+// named data pools in that order (e.g. cold before hot); pools not
+// listed follow in their spec order.
+func RestartOrder(pods []map[string]any, clusterName string, tierOrder []string) []map[string]any {
+	byPool := map[string][]map[string]any{}
+	var poolSeen []string
+	var masters []map[string]any
+	for _, pod := range pods {
+		if IsMasterPod(pod) {
+			masters = append(masters, pod)
+			continue
+		}
+		pool := PodPool(pod, clusterName)
+		if _, seen := byPool[pool]; !seen {
+			poolSeen = append(poolSeen, pool)
+		}
+		byPool[pool] = append(byPool[pool], pod)
+	}
+
+	var pools []string
+	for _, pool := range tierOrder {
+		if _, ok := byPool[pool]; ok {
+			pools = append(pools, pool)
+		}
+	}
+	for _, pool := range poolSeen {
+		listed := false
+		for _, p := range pools {
+			listed = listed || p == pool
+		}
+		if !listed {
+			pools = append(pools, pool)
+		}
+	}
+
+	order := make([]map[string]any, 0, len(pods))
+	for _, pool := range pools {
+		group := byPool[pool]
+		for i := len(group) - 1; i >= 0; i-- {
+			order = append(order, group[i])
+		}
+	}
+	for i := len(masters) - 1; i >= 0; i-- {
+		order = append(order, masters[i])
+	}
+	return order
+}
+
+// PodOrdinal extracts the StatefulSet ordinal from a pod name, or -1.
+func PodOrdinal(pod map[string]any) int {
+	name := unstructured.NestedString(pod, "metadata", "name")
+	idx := strings.LastIndex(name, "-")
+	if idx < 0 {
+		return -1
+	}
+	n, err := strconv.Atoi(name[idx+1:])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// PodReady reports whether the pod's Ready condition is True.
+func PodReady(pod map[string]any) bool {
+	for _, c := range unstructured.NestedSlice(pod, "status", "conditions") {
+		cond, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		if unstructured.NestedString(cond, "type") == "Ready" {
+			return unstructured.NestedString(cond, "status") == "True"
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package cratedb
+
+// Typed views of the CrateDB custom resource. Objects still arrive as
+// JSON maps from the apiserver; wrap converts them once into these
+// structs so the rest of the tool reads fields instead of digging
+// through nested maps. Fields not modelled here stay reachable via
+// Cluster.Obj.
+
+// Spec is the subset of a CrateDB spec the tool reads.
+type Spec struct {
+	Cluster ClusterSpec `json:"cluster"`
+	Nodes   NodesSpec   `json:"nodes"`
+}
+
+// ClusterSpec mirrors spec.cluster.
+type ClusterSpec struct {
+	Name     string         `json:"name"`
+	Version  string         `json:"version"`
+	Settings map[string]any `json:"settings"`
+	SSL      map[string]any `json:"ssl"`
+}
+
+// NodesSpec mirrors spec.nodes.
+type NodesSpec struct {
+	Master *MasterPool `json:"master"`
+	Data   []DataPool  `json:"data"`
+}
+
+// MasterPool mirrors one spec.nodes.master entry.
+type MasterPool struct {
+	Replicas int64 `json:"replicas"`
+}
+
+// DataPool mirrors one spec.nodes.data entry.
+type DataPool struct {
+	Name      string        `json:"name"`
+	Replicas  int64         `json:"replicas"`
+	Resources PoolResources `json:"resources"`
+}
+
+// PoolResources mirrors a data pool's resources block.
+type PoolResources struct {
+	Limits    map[string]any `json:"limits"`
+	Requests  map[string]any `json:"requests"`
+	HeapRatio float64        `json:"heapRatio"`
+	Disk      DiskResources  `json:"disk"`
+}
+
+// Limit returns the named resource limit (falling back to the request)
+// as a string, or "".
+func (r PoolResources) Limit(name string) string {
+	if v, ok := r.Limits[name]; ok {
+		return toString(v)
+	}
+	if v, ok := r.Requests[name]; ok {
+		return toString(v)
+	}
+	return ""
+}
+
+// DiskResources mirrors resources.disk.
+type DiskResources struct {
+	Size         string `json:"size"`
+	Count        int64  `json:"count"`
+	StorageClass string `json:"storageClass"`
+}
+
+// Status is the subset of a CrateDB status the tool reads.
+type Status struct {
+	CrateDBStatus CrateDBStatus `json:"crateDBStatus"`
+	Conditions    []Condition   `json:"conditions"`
+}
+
+// CrateDBStatus mirrors status.crateDBStatus.
+type CrateDBStatus struct {
+	Health string `json:"health"`
+}
+
+// Condition is one status condition.
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
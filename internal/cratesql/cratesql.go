@@ -0,0 +1,278 @@
+// Package cratesql executes SQL against a CrateDB cluster from
+// outside Kubernetes. It spawns `kubectl port-forward` to the crate
+// service and talks to the HTTP endpoint (/_sql) through the tunnel,
+// authenticating as the operator-managed system user.
+package cratesql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Client runs statements against one CrateDB cluster.
+type Client struct {
+	baseURL  string
+	user     string
+	password string
+	http     *http.Client
+	fwd      *exec.Cmd
+	readOnly bool
+}
+
+// Connect sets up a port-forward to the crate service of the cluster
+// and resolves the system user's password from its secret. Close must
+// be called to tear the tunnel down.
+func Connect(ctx context.Context, k *kube.Client, namespace, cluster string) (*Client, error) {
+	password, err := UserPassword(ctx, k, namespace, cluster, "system")
+	if err != nil {
+		return nil, err
+	}
+	return ConnectWith(ctx, k, namespace, cluster, "system", password)
+}
+
+// ConnectWith is Connect with explicit credentials.
+func ConnectWith(ctx context.Context, k *kube.Client, namespace, cluster, user, password string) (*Client, error) {
+	return connect(ctx, k, namespace, "svc/crate-"+cluster, user, password)
+}
+
+// ConnectPod tunnels to one specific pod instead of the service, for
+// checks that need a single node's own view of the cluster.
+func ConnectPod(ctx context.Context, k *kube.Client, namespace, cluster, pod string) (*Client, error) {
+	password, err := UserPassword(ctx, k, namespace, cluster, "system")
+	if err != nil {
+		return nil, err
+	}
+	return connect(ctx, k, namespace, "pod/"+pod, "system", password)
+}
+
+func connect(ctx context.Context, k *kube.Client, namespace, target, user, password string) (*Client, error) {
+
+	args := append(k.Config().KubectlArgs(), "port-forward", "-n", namespace, target, ":4200")
+	cmd := exec.Command("kubectl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting port-forward: %w", err)
+	}
+
+	// kubectl prints "Forwarding from 127.0.0.1:PORT -> 4200" once the
+	// tunnel is up.
+	portCh := make(chan string, 1)
+	go func() {
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			line := sc.Text()
+			if strings.HasPrefix(line, "Forwarding from 127.0.0.1:") {
+				rest := strings.TrimPrefix(line, "Forwarding from 127.0.0.1:")
+				if i := strings.Index(rest, " "); i > 0 {
+					portCh <- rest[:i]
+					return
+				}
+			}
+		}
+	}()
+	var port string
+	select {
+	case port = <-portCh:
+	case <-time.After(30 * time.Second):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("port-forward to %s %s did not come up", namespace, target)
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		return nil, ctx.Err()
+	}
+
+	return &Client{
+		baseURL:  "http://127.0.0.1:" + port,
+		user:     user,
+		password: password,
+		http:     &http.Client{Timeout: 60 * time.Second},
+		fwd:      cmd,
+		readOnly: k.ReadOnly(),
+	}, nil
+}
+
+// ConnectPodTrust tunnels to one pod without credentials. A
+// port-forward connection reaches CrateDB from loopback, which the
+// default host-based auth config trusts as the crate superuser — the
+// usual situation on Helm-installed clusters that have no
+// operator-managed users or secrets.
+func ConnectPodTrust(ctx context.Context, k *kube.Client, namespace, pod string) (*Client, error) {
+	return connect(ctx, k, namespace, "pod/"+pod, "crate", "")
+}
+
+// ProbePod tunnels to one pod and fetches the root endpoint of its
+// HTTP admin port, returning the server version. The root endpoint
+// answers without authentication, so the probe works even while SQL
+// credentials or the cluster itself are unavailable.
+func ProbePod(ctx context.Context, k *kube.Client, namespace, pod string) (string, error) {
+	c, err := connect(ctx, k, namespace, "pod/"+pod, "", "")
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("probing admin port: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("admin port returned HTTP %d", resp.StatusCode)
+	}
+	var out struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decoding probe response: %w", err)
+	}
+	return out.Version.Number, nil
+}
+
+// userSecretName is the operator's naming scheme for user credential
+// secrets; older operator series used a different layout (see
+// SetUserSecretNamer).
+var userSecretName = func(cluster, user string) string {
+	return "user-" + user + "-" + cluster
+}
+
+// SetUserSecretNamer overrides the credential-secret naming scheme,
+// for operator versions whose layout differs from the current one.
+func SetUserSecretNamer(f func(cluster, user string) string) {
+	if f != nil {
+		userSecretName = f
+	}
+}
+
+// UserSecretName is the operator's naming scheme for user credential
+// secrets.
+func UserSecretName(cluster, user string) string {
+	return userSecretName(cluster, user)
+}
+
+// UserPassword reads a user's password from its credential secret.
+func UserPassword(ctx context.Context, k *kube.Client, namespace, cluster, user string) (string, error) {
+	var secret map[string]any
+	path := kube.ResourcePath("v1", namespace, "secrets", UserSecretName(cluster, user))
+	if err := k.Get(ctx, path, &secret); err != nil {
+		return "", fmt.Errorf("reading credential secret for user %s: %w", user, err)
+	}
+	enc := unstructured.NestedString(secret, "data", "password")
+	raw, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", fmt.Errorf("decoding password for user %s: %w", user, err)
+	}
+	return string(raw), nil
+}
+
+// Ping verifies connectivity and credentials.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Exec(ctx, "SELECT 1")
+	return err
+}
+
+// Close tears down the port-forward.
+func (c *Client) Close() {
+	if c.fwd != nil && c.fwd.Process != nil {
+		c.fwd.Process.Kill()
+		c.fwd.Wait()
+	}
+}
+
+// Result is the response to one statement.
+type Result struct {
+	Cols     []string `json:"cols"`
+	Rows     [][]any  `json:"rows"`
+	RowCount int64    `json:"rowcount"`
+}
+
+// checkMutate mirrors the kube client's read-only guard for SQL: a
+// read-only binary inherits the bit through connect and may query but
+// not change database state, so commands like rotate-password or the
+// campaign's SET GLOBAL tweaks cannot write around the apiserver
+// guard through the tunnel.
+func (c *Client) checkMutate(stmt string) error {
+	if !c.readOnly {
+		return nil
+	}
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return fmt.Errorf("read-only mode: refusing empty statement")
+	}
+	switch head := strings.ToUpper(fields[0]); head {
+	case "SELECT", "SHOW", "EXPLAIN":
+		return nil
+	default:
+		return fmt.Errorf("read-only mode: refusing to execute %s statement", head)
+	}
+}
+
+// Exec runs a single parameterized statement.
+func (c *Client) Exec(ctx context.Context, stmt string, args ...any) (*Result, error) {
+	if err := c.checkMutate(stmt); err != nil {
+		return nil, err
+	}
+	payload := map[string]any{"stmt": stmt}
+	if len(args) > 0 {
+		payload["args"] = args
+	}
+	return c.post(ctx, payload)
+}
+
+// ExecBulk runs one statement with many argument rows.
+func (c *Client) ExecBulk(ctx context.Context, stmt string, bulkArgs [][]any) (*Result, error) {
+	if err := c.checkMutate(stmt); err != nil {
+		return nil, err
+	}
+	return c.post(ctx, map[string]any{"stmt": stmt, "bulk_args": bulkArgs})
+}
+
+func (c *Client) post(ctx context.Context, payload map[string]any) (*Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/_sql", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.user, c.password)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing SQL: %w", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Result
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding SQL response: %w", err)
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("cratedb: %s (code %d)", out.Error.Message, out.Error.Code)
+	}
+	return &out.Result, nil
+}
@@ -0,0 +1,93 @@
+// Package diagnostics collects postmortem material for a cluster
+// whose restart failed: the CR with status, pod manifests, recent
+// namespace events, and the tail of each crate pod's log, bundled
+// into one tarball.
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// LogTailLines is how much of each pod log ends up in the bundle.
+const LogTailLines = 500
+
+// Collect writes a tarball for namespace/cluster into dir and returns
+// its path. Collection is best-effort: pieces that cannot be fetched
+// are recorded as .error files inside the bundle instead of aborting.
+func Collect(ctx context.Context, k *kube.Client, namespace, cluster, dir string) (string, error) {
+	name := fmt.Sprintf("crt-diag-%s-%s-%s.tar.gz", namespace, cluster, time.Now().UTC().Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("creating diagnostics bundle: %w", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	defer gz.Close()
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	addJSON := func(name string, v any, err error) {
+		if err != nil {
+			addFile(tw, name+".error", []byte(err.Error()+"\n"))
+			return
+		}
+		b, merr := json.MarshalIndent(v, "", "  ")
+		if merr != nil {
+			addFile(tw, name+".error", []byte(merr.Error()+"\n"))
+			return
+		}
+		addFile(tw, name, append(b, '\n'))
+	}
+
+	cl, err := cratedb.Get(ctx, k, namespace, cluster)
+	addJSON("cratedb.json", cl.Obj, err)
+
+	events, err := k.List(ctx, kube.ResourcePath("v1", namespace, "events", ""), nil)
+	addJSON("events.json", events, err)
+
+	pods, err := cratedb.Pods(ctx, k, cl)
+	if err != nil {
+		addFile(tw, "pods.error", []byte(err.Error()+"\n"))
+	}
+	for _, pod := range pods {
+		podName := unstructured.NestedString(pod, "metadata", "name")
+		addJSON("pods/"+podName+".json", pod, nil)
+
+		logPath := kube.ResourcePath("v1", namespace, "pods", podName) + "/log"
+		q := url.Values{
+			"container": []string{"crate"},
+			"tailLines": []string{strconv.Itoa(LogTailLines)},
+		}
+		logs, err := k.RawGet(ctx, logPath, q)
+		if err != nil {
+			addFile(tw, "pods/"+podName+".log.error", []byte(err.Error()+"\n"))
+			continue
+		}
+		addFile(tw, "pods/"+podName+".log", logs)
+	}
+	return path, nil
+}
+
+func addFile(tw *tar.Writer, name string, data []byte) {
+	tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	tw.Write(data)
+}
@@ -0,0 +1,159 @@
+// Package history keeps a local append-only record of recovery
+// durations so slow clusters can be spotted over time. The store is a
+// JSON-lines file under the user's home directory; CronJob runs can
+// point it somewhere persistent with -history-file.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry is one recorded recovery. Kind is "pod" for a single pod
+// restart and "cluster" for a whole cluster.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	CampaignID string    `json:"campaign_id"`
+	Namespace  string    `json:"namespace"`
+	Cluster    string    `json:"cluster"`
+	Pod        string    `json:"pod,omitempty"`
+	Kind       string    `json:"kind"`
+	Seconds    float64   `json:"seconds"`
+	Status     string    `json:"status"`
+}
+
+// DefaultPath returns the default history file location.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".crt-history.jsonl"
+	}
+	return filepath.Join(home, ".crt", "history.jsonl")
+}
+
+// Append writes entries to the history file at path, creating parent
+// directories as needed.
+func Append(path string, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating history dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("writing history: %w", err)
+		}
+	}
+	return nil
+}
+
+// Read loads all entries from path. A missing file yields no entries.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue // tolerate partial lines from crashed runs
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// PodAverage returns the fleet-wide average duration of successful pod
+// restarts, or 0 when none are recorded. It seeds ETA estimates before
+// a campaign has produced observations of its own.
+func PodAverage(entries []Entry) time.Duration {
+	var sum float64
+	var runs int
+	for _, e := range entries {
+		if e.Kind == "pod" && e.Status == "succeeded" {
+			sum += e.Seconds
+			runs++
+		}
+	}
+	if runs == 0 {
+		return 0
+	}
+	return time.Duration(sum / float64(runs) * float64(time.Second))
+}
+
+// Trend summarizes one cluster's recoveries.
+type Trend struct {
+	Namespace string
+	Cluster   string
+	Runs      int
+	AvgSecs   float64
+	LastSecs  float64
+	LastTime  time.Time
+	// DeltaPct compares the most recent recovery against the average
+	// of the earlier ones; positive means it is getting slower.
+	DeltaPct float64
+}
+
+// Trends aggregates cluster-kind entries per cluster.
+func Trends(entries []Entry) []Trend {
+	type agg struct {
+		sum   float64
+		count int
+		last  Entry
+	}
+	byCluster := map[string]*agg{}
+	var order []string
+	for _, e := range entries {
+		if e.Kind != "cluster" || e.Status != "succeeded" {
+			continue
+		}
+		key := e.Namespace + "/" + e.Cluster
+		a, ok := byCluster[key]
+		if !ok {
+			a = &agg{}
+			byCluster[key] = a
+			order = append(order, key)
+		}
+		a.sum += e.Seconds
+		a.count++
+		a.last = e
+	}
+	trends := make([]Trend, 0, len(order))
+	for _, key := range order {
+		a := byCluster[key]
+		t := Trend{
+			Namespace: a.last.Namespace,
+			Cluster:   a.last.Cluster,
+			Runs:      a.count,
+			AvgSecs:   a.sum / float64(a.count),
+			LastSecs:  a.last.Seconds,
+			LastTime:  a.last.Time,
+		}
+		if a.count > 1 {
+			prevAvg := (a.sum - a.last.Seconds) / float64(a.count-1)
+			if prevAvg > 0 {
+				t.DeltaPct = (a.last.Seconds - prevAvg) / prevAvg * 100
+			}
+		}
+		trends = append(trends, t)
+	}
+	return trends
+}
@@ -0,0 +1,117 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Transition is one observed health change of a cluster.
+type Transition struct {
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+}
+
+// DefaultTransitionsPath returns the default transitions file location.
+func DefaultTransitionsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".crt-transitions.jsonl"
+	}
+	return filepath.Join(home, ".crt", "transitions.jsonl")
+}
+
+// AppendTransition records one transition.
+func AppendTransition(path string, t Transition) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating history dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening transitions: %w", err)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(t)
+}
+
+// ReadTransitions loads all transitions from path; a missing file
+// yields none.
+func ReadTransitions(path string) ([]Transition, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening transitions: %w", err)
+	}
+	defer f.Close()
+	var out []Transition
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		var t Transition
+		if err := json.Unmarshal(sc.Bytes(), &t); err != nil {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, sc.Err()
+}
+
+// HealthStats summarizes a cluster's health over a window.
+type HealthStats struct {
+	Window      time.Duration
+	GreenPct    float64
+	Transitions int // changes away from GREEN within the window
+	Current     string
+}
+
+// Stats computes GREEN percentage and incident count for one cluster
+// from its transitions, assuming the state before the first in-window
+// transition was that transition's From state (or GREEN when there is
+// no earlier evidence).
+func Stats(transitions []Transition, namespace, cluster string, window time.Duration, now time.Time) HealthStats {
+	start := now.Add(-window)
+	state := ""
+	var green time.Duration
+	cursor := start
+	stats := HealthStats{Window: window}
+
+	for _, t := range transitions {
+		if t.Namespace != namespace || t.Cluster != cluster {
+			continue
+		}
+		if t.Time.Before(start) {
+			state = t.To
+			continue
+		}
+		if state == "" {
+			state = t.From
+		}
+		if t.Time.After(cursor) {
+			if state == "GREEN" {
+				green += t.Time.Sub(cursor)
+			}
+			cursor = t.Time
+		}
+		if state == "GREEN" && t.To != "GREEN" {
+			stats.Transitions++
+		}
+		state = t.To
+	}
+	if state == "" {
+		state = "GREEN" // no evidence of anything else
+	}
+	if now.After(cursor) && state == "GREEN" {
+		green += now.Sub(cursor)
+	}
+	stats.GreenPct = float64(green) / float64(window) * 100
+	stats.Current = state
+	return stats
+}
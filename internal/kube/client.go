@@ -0,0 +1,472 @@
+// Package kube is a deliberately small Kubernetes REST client. It
+// speaks JSON to the apiserver directly so the tool stays a single
+// static binary without the client-go dependency tree.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client performs requests against a single apiserver, or serves
+// reads from an exported snapshot when built by NewOfflineClient.
+type Client struct {
+	cfg      *Config
+	http     *http.Client
+	offline  *offlineStore
+	fixtures *fixtureStore
+	recorder *Recorder
+	allowed  map[string]bool // namespace allow-list; nil = unrestricted
+	readOnly bool
+}
+
+// SetReadOnly makes every mutating method of this client fail. SQL
+// clients tunneled through this client inherit the bit (see
+// cratesql), so neither an apiserver write nor a port-forwarded SQL
+// statement can change state. Like the namespace allow-list, the
+// check sits below all command logic, so no flag combination or code
+// path can write through it.
+func (c *Client) SetReadOnly() { c.readOnly = true }
+
+// ReadOnly reports whether SetReadOnly was called, so derived clients
+// can extend the guard to their own mutations.
+func (c *Client) ReadOnly() bool { return c.readOnly }
+
+// checkMutate rejects op when the client is read-only.
+func (c *Client) checkMutate(op string) error {
+	if c.readOnly {
+		return fmt.Errorf("read-only mode: refusing to %s", op)
+	}
+	return nil
+}
+
+// RestrictNamespaces confines every request of this client to the
+// given namespaces. The check sits below all command logic, so no flag
+// combination can reach outside the list.
+func (c *Client) RestrictNamespaces(namespaces []string) {
+	c.allowed = map[string]bool{}
+	for _, ns := range namespaces {
+		c.allowed[ns] = true
+	}
+}
+
+// selfAccessReviewPath is the one POST endpoint exempt from the
+// read-only and namespace guards: a SelfSubjectAccessReview persists
+// nothing server-side and only answers what the caller may do.
+const selfAccessReviewPath = "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews"
+
+// checkAllowed rejects paths outside the namespace allow-list.
+func (c *Client) checkAllowed(path string) error {
+	if c.allowed == nil || path == selfAccessReviewPath {
+		return nil
+	}
+	ns, plural, _ := SplitResourcePath(path)
+	if ns == "" {
+		return fmt.Errorf("this binary is restricted to specific namespaces; scope the %s request with -n", plural)
+	}
+	if !c.allowed[ns] {
+		return fmt.Errorf("namespace %q is outside this binary's allow-list", ns)
+	}
+	return nil
+}
+
+// NewClient builds a Client from a resolved Config.
+func NewClient(cfg *Config) (*Client, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+	if len(cfg.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cfg.CAData) {
+			return nil, fmt.Errorf("no certificates parsed from CA data")
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if len(cfg.CertData) > 0 {
+		cert, err := tls.X509KeyPair(cfg.CertData, cfg.KeyData)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return &Client{
+		cfg: cfg,
+		http: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		},
+	}, nil
+}
+
+// Config returns the configuration the client was built from.
+func (c *Client) Config() *Config { return c.cfg }
+
+// Namespace returns the namespace from the kube context, or "default".
+func (c *Client) Namespace() string {
+	if c.cfg.Namespace != "" {
+		return c.cfg.Namespace
+	}
+	return "default"
+}
+
+// ResourcePath builds the REST path for a (possibly namespaced)
+// resource. Core-group resources use apiVersion "v1".
+func ResourcePath(apiVersion, namespace, plural, name string) string {
+	var b strings.Builder
+	if strings.Contains(apiVersion, "/") {
+		b.WriteString("/apis/" + apiVersion)
+	} else {
+		b.WriteString("/api/" + apiVersion)
+	}
+	if namespace != "" {
+		b.WriteString("/namespaces/" + namespace)
+	}
+	b.WriteString("/" + plural)
+	if name != "" {
+		b.WriteString("/" + name)
+	}
+	return b.String()
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, contentType string, body []byte) (*http.Response, error) {
+	if err := c.checkAllowed(path); err != nil {
+		return nil, err
+	}
+	if method != http.MethodGet && path != selfAccessReviewPath {
+		if err := c.checkMutate(method + " " + path); err != nil {
+			return nil, err
+		}
+	}
+	u := c.cfg.Server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.recorder != nil {
+		c.recorder.capture(method, path, query.Encode(), resp, query.Get("watch") == "true")
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, newStatusError(resp)
+	}
+	return resp, nil
+}
+
+// StatusError is an apiserver error response.
+type StatusError struct {
+	Code    int
+	Reason  string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("apiserver: %s (HTTP %d)", e.Message, e.Code)
+	}
+	return fmt.Sprintf("apiserver: HTTP %d", e.Code)
+}
+
+// IsNotFound reports whether err is a 404 from the apiserver.
+func IsNotFound(err error) bool {
+	se, ok := err.(*StatusError)
+	return ok && se.Code == http.StatusNotFound
+}
+
+func newStatusError(resp *http.Response) error {
+	se := &StatusError{Code: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var st struct {
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	}
+	if json.Unmarshal(body, &st) == nil {
+		se.Message = st.Message
+		se.Reason = st.Reason
+	}
+	return se
+}
+
+// Get fetches a single object into out (typically map[string]any).
+func (c *Client) Get(ctx context.Context, path string, out any) error {
+	if c.fixtures != nil {
+		return c.fixtures.get(path, out)
+	}
+	if c.offline != nil {
+		return c.offline.get(path, out)
+	}
+	resp, err := c.do(ctx, http.MethodGet, path, nil, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ListChunkSize is the page size List requests from the apiserver.
+const ListChunkSize = 500
+
+// List fetches a list and returns its items. Results are paged with
+// limit/continue in ListChunkSize chunks so listing pods or
+// StatefulSets on very large clusters neither buffers thousands of
+// objects server-side nor times out a single giant response.
+func (c *Client) List(ctx context.Context, path string, query url.Values) ([]map[string]any, error) {
+	if c.fixtures != nil {
+		return c.fixtures.list(path, query.Get("labelSelector")), nil
+	}
+	if c.offline != nil {
+		return c.offline.list(path, query.Get("labelSelector")), nil
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("limit", fmt.Sprint(ListChunkSize))
+
+	var items []map[string]any
+	for {
+		resp, err := c.do(ctx, http.MethodGet, path, q, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Metadata struct {
+				Continue string `json:"continue"`
+			} `json:"metadata"`
+			Items []map[string]any `json:"items"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, list.Items...)
+		if list.Metadata.Continue == "" {
+			return items, nil
+		}
+		q.Set("continue", list.Metadata.Continue)
+	}
+}
+
+// RawGet fetches a non-JSON endpoint such as pod logs.
+func (c *Client) RawGet(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	if c.offline != nil {
+		return nil, errOffline("raw get")
+	}
+	resp, err := c.do(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Create posts a new object (e.g. an Event).
+func (c *Client) Create(ctx context.Context, path string, obj any) error {
+	if err := c.checkMutate("create " + path); err != nil {
+		return err
+	}
+	if c.fixtures != nil {
+		return nil // accepted and ignored during playback
+	}
+	if c.offline != nil {
+		return errOffline("create")
+	}
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, path, nil, "application/json", body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// CanI asks the apiserver whether the current identity may perform
+// verb on group/resource in namespace, via a SelfSubjectAccessReview.
+// The core API group is the empty string.
+func (c *Client) CanI(ctx context.Context, namespace, group, resource, verb string) (bool, error) {
+	if c.fixtures != nil || c.offline != nil {
+		return true, nil // snapshots carry no authorizer; assume allowed
+	}
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "authorization.k8s.io/v1",
+		"kind":       "SelfSubjectAccessReview",
+		"spec": map[string]any{
+			"resourceAttributes": map[string]any{
+				"namespace": namespace,
+				"group":     group,
+				"resource":  resource,
+				"verb":      verb,
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(ctx, http.MethodPost, selfAccessReviewPath, nil, "application/json", body)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	var review struct {
+		Status struct {
+			Allowed bool `json:"allowed"`
+		} `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+// Delete removes an object.
+func (c *Client) Delete(ctx context.Context, path string) error {
+	if err := c.checkMutate("delete " + path); err != nil {
+		return err
+	}
+	if c.fixtures != nil {
+		return c.fixtures.delete(path)
+	}
+	if c.offline != nil {
+		return errOffline("delete")
+	}
+	resp, err := c.do(ctx, http.MethodDelete, path, nil, "", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Patch applies a merge patch (RFC 7386) to an object. Prefer Apply
+// for anything the tool mutates; Patch remains for endpoints that do
+// not support apply.
+func (c *Client) Patch(ctx context.Context, path string, patch any) error {
+	if err := c.checkMutate("patch " + path); err != nil {
+		return err
+	}
+	if c.fixtures != nil {
+		return nil // accepted and ignored during playback
+	}
+	if c.offline != nil {
+		return errOffline("patch")
+	}
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPatch, path, nil, "application/merge-patch+json", body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// FieldManager identifies this tool in server-side apply operations.
+const FieldManager = "crt"
+
+// Apply performs a server-side apply of obj (which must carry
+// apiVersion, kind, and metadata.name) so the tool's changes are
+// attributed to its own field manager and never clobber fields owned
+// by the operator.
+func (c *Client) Apply(ctx context.Context, path string, obj any) error {
+	if err := c.checkMutate("apply " + path); err != nil {
+		return err
+	}
+	if c.fixtures != nil {
+		return nil // accepted and ignored during playback
+	}
+	if c.offline != nil {
+		return errOffline("apply")
+	}
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	q := url.Values{
+		"fieldManager": []string{FieldManager},
+		"force":        []string{"true"},
+	}
+	resp, err := c.do(ctx, http.MethodPatch, path+"?"+q.Encode(), nil, "application/apply-patch+yaml", body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// WatchEvent is one entry from a watch stream.
+type WatchEvent struct {
+	Type   string
+	Object map[string]any
+}
+
+// Watch opens a watch on path and sends events until ctx is cancelled
+// or the server closes the stream.
+func (c *Client) Watch(ctx context.Context, path string, query url.Values, events chan<- WatchEvent) error {
+	if c.fixtures != nil {
+		return c.fixtures.watch(ctx, path, query, events)
+	}
+	if c.offline != nil {
+		return errOffline("watch")
+	}
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("watch", "true")
+	resp, err := c.do(ctx, http.MethodGet, path, q, "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var ev struct {
+			Type   string         `json:"type"`
+			Object map[string]any `json:"object"`
+		}
+		if err := dec.Decode(&ev); err != nil {
+			if ctx.Err() != nil || err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("decoding watch event: %w", err)
+		}
+		select {
+		case events <- WatchEvent{Type: ev.Type, Object: ev.Object}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// WaitBackoff is the pause between poll attempts used by callers that
+// wait for state changes.
+const WaitBackoff = 5 * time.Second
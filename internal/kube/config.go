@@ -0,0 +1,213 @@
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Config holds everything needed to talk to one kube-apiserver.
+type Config struct {
+	Server     string
+	CAData     []byte
+	CertData   []byte
+	KeyData    []byte
+	Token      string
+	Insecure   bool
+	Context    string
+	Namespace  string
+	Kubeconfig string // explicit kubeconfig path, for kubectl subprocesses
+	User       string // kubeconfig user name or in-cluster service account
+}
+
+// KubectlArgs returns the kubectl flags selecting this configuration,
+// for subprocesses like port-forward.
+func (c *Config) KubectlArgs() []string {
+	var args []string
+	if c.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", c.Kubeconfig)
+	}
+	if c.Context != "" {
+		args = append(args, "--context", c.Context)
+	}
+	return args
+}
+
+// LoadConfig resolves client configuration. Inside a pod the mounted
+// service account is used; otherwise the kubeconfig is read via
+// `kubectl config view --raw -o json` so we do not have to carry a YAML
+// parser. An empty kubecontext selects the current context; kubeconfig
+// overrides the default kubeconfig resolution (like kubectl's
+// --kubeconfig).
+func LoadConfig(kubecontext, kubeconfig string) (*Config, error) {
+	if host := os.Getenv("KUBERNETES_SERVICE_HOST"); host != "" && kubecontext == "" && kubeconfig == "" {
+		return inClusterConfig(host)
+	}
+	return kubeconfigConfig(kubecontext, kubeconfig)
+}
+
+func inClusterConfig(host string) (*Config, error) {
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("reading service account token: %w", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("reading service account CA: %w", err)
+	}
+	ns, _ := os.ReadFile(serviceAccountDir + "/namespace")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	if port == "" {
+		port = "443"
+	}
+	return &Config{
+		Server:    "https://" + host + ":" + port,
+		CAData:    ca,
+		Token:     strings.TrimSpace(string(token)),
+		Namespace: strings.TrimSpace(string(ns)),
+		User:      "system:serviceaccount:" + strings.TrimSpace(string(ns)),
+	}, nil
+}
+
+// kubeconfigJSON mirrors the subset of `kubectl config view -o json`
+// output we care about.
+type kubeconfigJSON struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			ClientCertificateData string `json:"client-certificate-data"`
+			ClientCertificate     string `json:"client-certificate"`
+			ClientKeyData         string `json:"client-key-data"`
+			ClientKey             string `json:"client-key"`
+			Token                 string `json:"token"`
+			TokenFile             string `json:"tokenFile"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+func kubeconfigConfig(kubecontext, kubeconfig string) (*Config, error) {
+	args := []string{"config", "view", "--raw", "-o", "json"}
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig via kubectl: %w", err)
+	}
+	var kc kubeconfigJSON
+	if err := json.Unmarshal(out, &kc); err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	if kubecontext == "" {
+		kubecontext = kc.CurrentContext
+	}
+	if kubecontext == "" {
+		return nil, fmt.Errorf("no current kube context set")
+	}
+
+	cfg := &Config{Context: kubecontext, Kubeconfig: kubeconfig}
+	var clusterName, userName string
+	for _, c := range kc.Contexts {
+		if c.Name == kubecontext {
+			clusterName = c.Context.Cluster
+			userName = c.Context.User
+			cfg.Namespace = c.Context.Namespace
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("kube context %q not found", kubecontext)
+	}
+	cfg.User = userName
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		cfg.Server = c.Cluster.Server
+		cfg.Insecure = c.Cluster.InsecureSkipTLSVerify
+		if cfg.CAData, err = b64OrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if cfg.CertData, err = b64OrFile(u.User.ClientCertificateData, u.User.ClientCertificate); err != nil {
+			return nil, err
+		}
+		if cfg.KeyData, err = b64OrFile(u.User.ClientKeyData, u.User.ClientKey); err != nil {
+			return nil, err
+		}
+		cfg.Token = u.User.Token
+		if cfg.Token == "" && u.User.TokenFile != "" {
+			b, err := os.ReadFile(u.User.TokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading token file: %w", err)
+			}
+			cfg.Token = strings.TrimSpace(string(b))
+		}
+	}
+	return cfg, nil
+}
+
+// Contexts returns the names of every context in the kubeconfig, with
+// the current context first.
+func Contexts(kubeconfig string) ([]string, error) {
+	args := []string{"config", "view", "-o", "json"}
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig via kubectl: %w", err)
+	}
+	var kc kubeconfigJSON
+	if err := json.Unmarshal(out, &kc); err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	var names []string
+	for _, c := range kc.Contexts {
+		if c.Name == kc.CurrentContext {
+			names = append([]string{c.Name}, names...)
+		} else {
+			names = append(names, c.Name)
+		}
+	}
+	return names, nil
+}
+
+func b64OrFile(data, path string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path != "" {
+		return os.ReadFile(path)
+	}
+	return nil, nil
+}
@@ -0,0 +1,211 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// fixtureScript tunes how the fixture store plays back state changes.
+// It lives in script.json next to the fixture objects.
+type fixtureScript struct {
+	// PodRestartSeconds is how long a deleted pod stays gone before it
+	// reappears Ready with a fresh UID.
+	PodRestartSeconds float64 `json:"pod_restart_seconds"`
+	// HealthRecoverySeconds is how long clusters in the pod's namespace
+	// report YELLOW after the pod returns.
+	HealthRecoverySeconds float64 `json:"health_recovery_seconds"`
+}
+
+// fixtureStore serves all Kubernetes interactions from recorded JSON
+// fixtures, with scripted pod restarts and health transitions. It
+// turns the campaign engine into something that can be demoed and
+// end-to-end tested without a cluster: deletes "work", pods come back
+// after a scripted delay, and health dips and recovers.
+type fixtureStore struct {
+	offlineStore
+	script fixtureScript
+
+	mu sync.Mutex
+	// deleted maps pod ns/name to its deletion time.
+	deleted map[string]time.Time
+	// degradedUntil maps a namespace to when its clusters go GREEN again.
+	degradedUntil map[string]time.Time
+}
+
+// NewFixtureClient builds a Client over the fixture directory dir: the
+// <plural>.json files a snapshot export writes, plus an optional
+// script.json with playback timings.
+func NewFixtureClient(dir string) (*Client, error) {
+	base, err := NewOfflineClient(dir)
+	if err != nil {
+		return nil, err
+	}
+	store := &fixtureStore{
+		offlineStore:  *base.offline,
+		script:        fixtureScript{PodRestartSeconds: 3, HealthRecoverySeconds: 5},
+		deleted:       map[string]time.Time{},
+		degradedUntil: map[string]time.Time{},
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "script.json")); err == nil {
+		if err := json.Unmarshal(b, &store.script); err != nil {
+			return nil, fmt.Errorf("parsing fixture script: %w", err)
+		}
+	}
+	return &Client{cfg: &Config{Server: "fixtures:" + dir}, fixtures: store}, nil
+}
+
+func (s *fixtureStore) restartDelay() time.Duration {
+	return time.Duration(s.script.PodRestartSeconds * float64(time.Second))
+}
+
+func (s *fixtureStore) get(path string, out any) error {
+	namespace, plural, name := SplitResourcePath(path)
+	obj, err := s.current(namespace, plural, name)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (s *fixtureStore) list(path, selector string) []map[string]any {
+	namespace, plural, _ := SplitResourcePath(path)
+	var items []map[string]any
+	for _, obj := range s.objects[plural] {
+		ns := unstructured.NestedString(obj, "metadata", "namespace")
+		name := unstructured.NestedString(obj, "metadata", "name")
+		if namespace != "" && ns != namespace {
+			continue
+		}
+		if !matchesSelector(obj, selector) {
+			continue
+		}
+		cur, err := s.current(ns, plural, name)
+		if err != nil {
+			continue // pod currently "terminated"
+		}
+		items = append(items, cur)
+	}
+	return items
+}
+
+// current returns the object's present scripted state: deleted pods
+// are gone or reborn depending on elapsed time, and cluster health
+// reflects any in-flight recovery.
+func (s *fixtureStore) current(namespace, plural, name string) (map[string]any, error) {
+	var found map[string]any
+	for _, obj := range s.objects[plural] {
+		if unstructured.NestedString(obj, "metadata", "namespace") == namespace &&
+			unstructured.NestedString(obj, "metadata", "name") == name {
+			found = obj
+			break
+		}
+	}
+	if found == nil {
+		return nil, &StatusError{Code: 404, Reason: "NotFound", Message: fmt.Sprintf("%s %q not found in fixtures", plural, name)}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch plural {
+	case "pods":
+		key := namespace + "/" + name
+		if at, ok := s.deleted[key]; ok {
+			if time.Since(at) < s.restartDelay() {
+				return nil, &StatusError{Code: 404, Reason: "NotFound", Message: "pod is restarting"}
+			}
+			return rebornPod(found, at), nil
+		}
+	case "cratedbs":
+		if until, ok := s.degradedUntil[namespace]; ok && time.Now().Before(until) {
+			return withHealth(found, "YELLOW"), nil
+		}
+		return withHealth(found, "GREEN"), nil
+	}
+	return found, nil
+}
+
+func (s *fixtureStore) delete(path string) error {
+	namespace, plural, name := SplitResourcePath(path)
+	if plural != "pods" {
+		return errOffline("delete of " + plural)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.deleted[namespace+"/"+name] = now
+	s.degradedUntil[namespace] = now.Add(s.restartDelay() +
+		time.Duration(s.script.HealthRecoverySeconds*float64(time.Second)))
+	return nil
+}
+
+// watch synthesizes MODIFIED events from the scripted state twice a
+// second until the stream is cancelled.
+func (s *fixtureStore) watch(ctx context.Context, path string, query url.Values, events chan<- WatchEvent) error {
+	nameFilter := strings.TrimPrefix(query.Get("fieldSelector"), "metadata.name=")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(500 * time.Millisecond):
+		}
+		for _, obj := range s.list(path, query.Get("labelSelector")) {
+			if nameFilter != "" && unstructured.NestedString(obj, "metadata", "name") != nameFilter {
+				continue
+			}
+			select {
+			case events <- WatchEvent{Type: "MODIFIED", Object: obj}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// rebornPod is the deleted pod come back: fresh UID, Ready.
+func rebornPod(obj map[string]any, deletedAt time.Time) map[string]any {
+	out := deepCopy(obj)
+	meta, _ := out["metadata"].(map[string]any)
+	if meta != nil {
+		meta["uid"] = fmt.Sprintf("%v-%d", meta["uid"], deletedAt.UnixNano())
+	}
+	out["status"] = map[string]any{
+		"conditions": []any{map[string]any{"type": "Ready", "status": "True"}},
+		"phase":      "Running",
+	}
+	return out
+}
+
+func withHealth(obj map[string]any, health string) map[string]any {
+	out := deepCopy(obj)
+	status, _ := out["status"].(map[string]any)
+	if status == nil {
+		status = map[string]any{}
+		out["status"] = status
+	}
+	crate, _ := status["crateDBStatus"].(map[string]any)
+	if crate == nil {
+		crate = map[string]any{}
+		status["crateDBStatus"] = crate
+	}
+	crate["health"] = health
+	return out
+}
+
+func deepCopy(obj map[string]any) map[string]any {
+	b, _ := json.Marshal(obj)
+	var out map[string]any
+	json.Unmarshal(b, &out)
+	return out
+}
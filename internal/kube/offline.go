@@ -0,0 +1,129 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// offlineStore serves objects from an exported snapshot directory
+// instead of an apiserver, so list/plan logic can run against a
+// point-in-time copy of a cluster without any access to it.
+type offlineStore struct {
+	// objects indexes exported objects by their resource plural
+	// ("cratedbs", "statefulsets", "pods").
+	objects map[string][]map[string]any
+}
+
+// NewOfflineClient builds a read-only Client backed by the snapshot in
+// dir (one <plural>.json file per resource, as written by `crt
+// snapshot`). Mutating and streaming calls fail.
+func NewOfflineClient(dir string) (*Client, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no snapshot files in %s", dir)
+	}
+	store := &offlineStore{objects: map[string][]map[string]any{}}
+	for _, f := range files {
+		if filepath.Base(f) == "script.json" {
+			continue // fixture playback script, not objects
+		}
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+		var objs []map[string]any
+		if err := json.Unmarshal(b, &objs); err != nil {
+			return nil, fmt.Errorf("parsing snapshot %s: %w", f, err)
+		}
+		plural := strings.TrimSuffix(filepath.Base(f), ".json")
+		store.objects[plural] = objs
+	}
+	return &Client{cfg: &Config{Server: "offline:" + dir}, offline: store}, nil
+}
+
+// SplitResourcePath splits a path built by ResourcePath back into
+// namespace, plural and (for single-object paths) name.
+func SplitResourcePath(path string) (namespace, plural, name string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// Strip the prefix ResourcePath added: /api/v1 for the core group,
+	// /apis/<group>/<version> otherwise.
+	switch {
+	case len(parts) >= 3 && parts[0] == "apis":
+		parts = parts[3:]
+	case len(parts) >= 2 && parts[0] == "api":
+		parts = parts[2:]
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		namespace = parts[1]
+		parts = parts[2:]
+	}
+	if len(parts) > 0 {
+		plural = parts[0]
+	}
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+	return namespace, plural, name
+}
+
+// matchesSelector applies an equality-based label selector ("k=v,k2=v2")
+// to an object's metadata.labels. The empty selector matches.
+func matchesSelector(obj map[string]any, selector string) bool {
+	if selector == "" {
+		return true
+	}
+	labels := unstructured.NestedMap(obj, "metadata", "labels")
+	for _, pair := range strings.Split(selector, ",") {
+		k, v, found := strings.Cut(pair, "=")
+		if !found {
+			return false
+		}
+		if fmt.Sprint(labels[k]) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *offlineStore) get(path string, out any) error {
+	namespace, plural, name := SplitResourcePath(path)
+	for _, obj := range s.objects[plural] {
+		if unstructured.NestedString(obj, "metadata", "namespace") == namespace &&
+			unstructured.NestedString(obj, "metadata", "name") == name {
+			b, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(b, out)
+		}
+	}
+	return &StatusError{Code: 404, Reason: "NotFound", Message: fmt.Sprintf("%s %q not found in snapshot", plural, name)}
+}
+
+func (s *offlineStore) list(path, selector string) []map[string]any {
+	namespace, plural, _ := SplitResourcePath(path)
+	var items []map[string]any
+	for _, obj := range s.objects[plural] {
+		if namespace != "" && unstructured.NestedString(obj, "metadata", "namespace") != namespace {
+			continue
+		}
+		if !matchesSelector(obj, selector) {
+			continue
+		}
+		items = append(items, obj)
+	}
+	return items
+}
+
+// errOffline is returned for operations a snapshot cannot serve.
+func errOffline(op string) error {
+	return fmt.Errorf("%s is not available against an offline snapshot", op)
+}
@@ -0,0 +1,88 @@
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record is one captured API interaction. Response bodies are kept for
+// reads, so a recording holds everything needed to reconstruct what
+// the tool saw when it made a decision.
+type Record struct {
+	Seq      int             `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Query    string          `json:"query,omitempty"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// Recorder appends captured interactions to a JSON-lines file.
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	seq int
+}
+
+// Record starts capturing every API interaction of this client to
+// path, for later inspection with `crt replay`.
+func (c *Client) Record(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening recording: %w", err)
+	}
+	c.recorder = &Recorder{f: f}
+	return nil
+}
+
+// capture logs one interaction, buffering and restoring the response
+// body for reads. Streaming responses (watches) are logged without a
+// body.
+func (r *Recorder) capture(method, path, query string, resp *http.Response, stream bool) {
+	rec := Record{Time: time.Now(), Method: method, Path: path, Query: query}
+	if resp != nil {
+		rec.Status = resp.StatusCode
+		if method == http.MethodGet && !stream {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil && json.Valid(body) {
+				rec.Response = body
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	rec.Seq = r.seq
+	enc := json.NewEncoder(r.f)
+	enc.Encode(rec)
+}
+
+// ReadRecords loads a recording written by Record.
+func ReadRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording: %w", err)
+	}
+	defer f.Close()
+	var records []Record
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	for sc.Scan() {
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("parsing recording: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, sc.Err()
+}
@@ -0,0 +1,56 @@
+// Package notify posts short campaign outcome messages to per-tenant
+// webhooks, so each team hears about its own clusters instead of one
+// global firehose channel. The payload is {"text": "..."}, which Slack
+// incoming webhooks and most chat bridges accept as-is.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Router maps tenant label values to webhook URLs. The "*" entry, when
+// present, receives messages for clusters without a tenant and for
+// tenants with no route of their own.
+type Router struct {
+	webhooks map[string]string
+}
+
+// NewRouter builds a router from the tenant_webhooks config map; a nil
+// or empty map yields a router that drops everything.
+func NewRouter(webhooks map[string]string) *Router {
+	return &Router{webhooks: webhooks}
+}
+
+// Send posts text to the tenant's webhook. Unroutable messages are
+// dropped silently: not every team opts into notifications.
+func (r *Router) Send(ctx context.Context, tenant, text string) error {
+	url, ok := r.webhooks[tenant]
+	if !ok {
+		url, ok = r.webhooks["*"]
+	}
+	if !ok || url == "" {
+		return nil
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifying %s: %w", tenant, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notifying %s: webhook returned HTTP %d", tenant, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package objstore uploads small artifacts (reports, audit logs) to
+// object storage. It supports s3://, gs:// and az:// URLs with just
+// the standard library: S3 requests are SigV4-signed from the usual
+// AWS_* environment variables, GCS uses a bearer token
+// (GOOGLE_OAUTH_ACCESS_TOKEN or the instance metadata server), and
+// Azure expects a SAS token in AZURE_STORAGE_SAS_TOKEN.
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// Upload stores body under <dest>/<name>, where dest is an
+// s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix URL.
+func Upload(ctx context.Context, dest, name string, body []byte) error {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return fmt.Errorf("parsing upload destination: %w", err)
+	}
+	key := strings.TrimPrefix(path.Join(u.Path, name), "/")
+	switch u.Scheme {
+	case "s3":
+		return uploadS3(ctx, u.Host, key, body)
+	case "gs":
+		return uploadGCS(ctx, u.Host, key, body)
+	case "az":
+		return uploadAzure(ctx, u.Host, key, body)
+	default:
+		return fmt.Errorf("unsupported upload scheme %q (want s3, gs or az)", u.Scheme)
+	}
+}
+
+func uploadS3(ctx context.Context, bucket, key string, body []byte) error {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("s3 upload requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL")
+	var u string
+	if endpoint != "" {
+		u = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key
+	} else {
+		u = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signV4(req, body, region, accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"))
+	return doUpload(req, "s3")
+}
+
+func uploadGCS(ctx context.Context, bucket, key string, body []byte) error {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = metadataToken(ctx); err != nil {
+			return fmt.Errorf("gcs upload: no GOOGLE_OAUTH_ACCESS_TOKEN and no metadata server: %w", err)
+		}
+	}
+	u := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		bucket, url.QueryEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doUpload(req, "gcs")
+}
+
+func uploadAzure(ctx context.Context, host, key string, body []byte) error {
+	sas := os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	if sas == "" {
+		return fmt.Errorf("azure upload requires AZURE_STORAGE_SAS_TOKEN")
+	}
+	// az://account/container/prefix: host is the account, the first
+	// path element the container.
+	container, blob, found := strings.Cut(key, "/")
+	if !found {
+		return fmt.Errorf("az destination needs account/container/prefix")
+	}
+	u := fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s?%s",
+		host, container, blob, strings.TrimPrefix(sas, "?"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("Content-Type", "application/json")
+	return doUpload(req, "azure")
+}
+
+func metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	// {"access_token":"...","expires_in":...}
+	const marker = `"access_token":"`
+	i := strings.Index(string(b), marker)
+	if i < 0 {
+		return "", fmt.Errorf("no access_token in metadata response")
+	}
+	rest := string(b)[i+len(marker):]
+	if j := strings.Index(rest, `"`); j > 0 {
+		return rest[:j], nil
+	}
+	return "", fmt.Errorf("malformed metadata response")
+}
+
+func doUpload(req *http.Request, backend string) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s upload: %w", backend, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("%s upload: HTTP %d: %s", backend, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package operator detects the installed crate-operator version and
+// adapts the tool's conventions — health status path, credential
+// secret naming — to the series actually running in the cluster.
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// operatorSelector matches the crate-operator's own workload objects.
+const operatorSelector = "app.kubernetes.io/name=crate-operator"
+
+// Detect finds the installed crate-operator version: first from the
+// operator Deployment's app.kubernetes.io/version label or image tag,
+// then from the CRD's annotations.
+func Detect(ctx context.Context, c *kube.Client) (string, error) {
+	deps, err := c.List(ctx, kube.ResourcePath("apps/v1", "", "deployments", ""),
+		url.Values{"labelSelector": []string{operatorSelector}})
+	if err == nil {
+		for _, dep := range deps {
+			if v := unstructured.NestedString(dep, "metadata", "labels", "app.kubernetes.io/version"); v != "" {
+				return v, nil
+			}
+			for _, container := range unstructured.NestedSlice(dep, "spec", "template", "spec", "containers") {
+				cm, ok := container.(map[string]any)
+				if !ok {
+					continue
+				}
+				if _, tag, found := strings.Cut(unstructured.NestedString(cm, "image"), ":"); found && tag != "latest" {
+					return tag, nil
+				}
+			}
+		}
+	}
+	var crd map[string]any
+	crdPath := kube.ResourcePath("apiextensions.k8s.io/v1", "", "customresourcedefinitions",
+		cratedb.Plural+"."+strings.Split(cratedb.APIVersion, "/")[0])
+	if err := c.Get(ctx, crdPath, &crd); err != nil {
+		return "", fmt.Errorf("operator version not found on the Deployment or CRD: %w", err)
+	}
+	if v := unstructured.NestedString(crd, "metadata", "annotations", "app.kubernetes.io/version"); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("operator version not found on the Deployment or CRD")
+}
+
+// CheckAvailable verifies the crate-operator Deployment has all
+// replicas ready and that any admission webhooks it registers resolve
+// to a service with ready endpoints. Restarting pods while the
+// operator is down or its webhook unreachable leaves clusters waiting
+// for a reconcile that never comes.
+func CheckAvailable(ctx context.Context, c *kube.Client) error {
+	deps, err := c.List(ctx, kube.ResourcePath("apps/v1", "", "deployments", ""),
+		url.Values{"labelSelector": []string{operatorSelector}})
+	if err != nil {
+		return fmt.Errorf("looking up the operator Deployment: %w", err)
+	}
+	if len(deps) == 0 {
+		return fmt.Errorf("no Deployment matches %s; is the operator installed?", operatorSelector)
+	}
+	for _, dep := range deps {
+		name := unstructured.NestedString(dep, "metadata", "namespace") + "/" +
+			unstructured.NestedString(dep, "metadata", "name")
+		want := unstructured.NestedInt64(dep, "spec", "replicas")
+		ready := unstructured.NestedInt64(dep, "status", "readyReplicas")
+		if want == 0 {
+			return fmt.Errorf("operator Deployment %s is scaled to zero", name)
+		}
+		if ready < want {
+			return fmt.Errorf("operator Deployment %s has %d of %d replicas ready", name, ready, want)
+		}
+	}
+	return checkWebhooks(ctx, c)
+}
+
+// checkWebhooks resolves the operator's admission webhooks to their
+// backing endpoints. A webhook whose service has no ready addresses
+// blocks every CR update the apiserver sends through it.
+func checkWebhooks(ctx context.Context, c *kube.Client) error {
+	for _, plural := range []string{"validatingwebhookconfigurations", "mutatingwebhookconfigurations"} {
+		cfgs, err := c.List(ctx, kube.ResourcePath("admissionregistration.k8s.io/v1", "", plural, ""),
+			url.Values{"labelSelector": []string{operatorSelector}})
+		if err != nil {
+			return fmt.Errorf("listing %s: %w", plural, err)
+		}
+		for _, cfg := range cfgs {
+			for _, wh := range unstructured.NestedSlice(cfg, "webhooks") {
+				whm, ok := wh.(map[string]any)
+				if !ok {
+					continue
+				}
+				svcNS := unstructured.NestedString(whm, "clientConfig", "service", "namespace")
+				svcName := unstructured.NestedString(whm, "clientConfig", "service", "name")
+				if svcName == "" {
+					continue // URL-based webhook; nothing to resolve in-cluster
+				}
+				var ep map[string]any
+				if err := c.Get(ctx, kube.ResourcePath("v1", svcNS, "endpoints", svcName), &ep); err != nil {
+					return fmt.Errorf("webhook %s: endpoints %s/%s: %w",
+						unstructured.NestedString(whm, "name"), svcNS, svcName, err)
+				}
+				if !hasReadyAddresses(ep) {
+					return fmt.Errorf("webhook %s points at service %s/%s, which has no ready endpoints",
+						unstructured.NestedString(whm, "name"), svcNS, svcName)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasReadyAddresses(ep map[string]any) bool {
+	for _, ss := range unstructured.NestedSlice(ep, "subsets") {
+		if m, ok := ss.(map[string]any); ok && len(unstructured.NestedSlice(m, "addresses")) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// profile captures what changed between operator series, as far as
+// this tool is concerned. Zero fields mean the current conventions,
+// which the rest of the tool defaults to.
+type profile struct {
+	healthPath string
+	secretName func(cluster, user string) string
+}
+
+// profiles keys the known series by major version. 2.x is the series
+// the tool is built and tested against; 1.x predates the
+// crateDBStatus status wrapper and named credential secrets with the
+// cluster first.
+var profiles = map[string]profile{
+	"2": {},
+	"1": {
+		healthPath: "status.health",
+		secretName: func(cluster, user string) string { return cluster + "-user-" + user },
+	},
+}
+
+// Apply configures version-dependent behaviors for the detected
+// operator version and warns when the series is one this tool has not
+// been exercised against. Explicit -health-path style overrides should
+// be applied after Apply so they win.
+func Apply(version string) {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	p, known := profiles[major]
+	if !known {
+		cli.Warnf("crate-operator %s is not a series this tool has been tested against; assuming current conventions", version)
+		return
+	}
+	cli.Debugf("crate-operator %s detected", version)
+	if p.healthPath != "" {
+		cratedb.SetHealthMapping(p.healthPath, nil)
+	}
+	if p.secretName != nil {
+		cratesql.SetUserSecretNamer(p.secretName)
+	}
+}
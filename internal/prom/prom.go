@@ -0,0 +1,130 @@
+// Package prom evaluates instant queries against a Prometheus server.
+// It backs the generic -prom-gate restart gate, which lets teams put
+// their own SLO expressions (consumer lag, error budgets) in front of
+// each pod restart.
+package prom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Gate is a parsed "expr OP threshold" condition. The gate passes when
+// the expression's current value satisfies the comparison.
+type Gate struct {
+	Expr      string
+	Op        string // <, <=, > or >=
+	Threshold float64
+}
+
+var gatePattern = regexp.MustCompile(`^(.+?)\s*(<=|>=|<|>)\s*([-+0-9.eE]+)$`)
+
+// ParseGate splits a condition like "sum(rate(errors[5m])) < 0.1" into
+// its expression, comparison and threshold.
+func ParseGate(s string) (Gate, error) {
+	m := gatePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Gate{}, fmt.Errorf("invalid gate %q (want 'expr < threshold' or 'expr > threshold')", s)
+	}
+	threshold, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return Gate{}, fmt.Errorf("invalid gate threshold %q: %w", m[3], err)
+	}
+	return Gate{Expr: m[1], Op: m[2], Threshold: threshold}, nil
+}
+
+func (g Gate) String() string {
+	return fmt.Sprintf("%s %s %g", g.Expr, g.Op, g.Threshold)
+}
+
+// Check evaluates the gate against the Prometheus at baseURL and
+// returns an error describing the violation when it does not hold.
+func (g Gate) Check(ctx context.Context, baseURL string) error {
+	v, err := Query(ctx, baseURL, g.Expr)
+	if err != nil {
+		return fmt.Errorf("prom gate: %w", err)
+	}
+	holds := false
+	switch g.Op {
+	case "<":
+		holds = v < g.Threshold
+	case "<=":
+		holds = v <= g.Threshold
+	case ">":
+		holds = v > g.Threshold
+	case ">=":
+		holds = v >= g.Threshold
+	}
+	if !holds {
+		return fmt.Errorf("prom gate %q not satisfied: current value %g", g.String(), v)
+	}
+	return nil
+}
+
+// Query runs an instant query and returns its single sample value.
+// Expressions yielding no series or more than one are errors — a gate
+// must be unambiguous to mean anything.
+func Query(ctx context.Context, baseURL, expr string) (float64, error) {
+	u := strings.TrimSuffix(baseURL, "/") + "/api/v1/query?query=" + url.QueryEscape(expr)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("querying prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("decoding prometheus response: %w", err)
+	}
+	if out.Status != "success" {
+		return 0, fmt.Errorf("prometheus: %s", out.Error)
+	}
+	switch out.Data.ResultType {
+	case "scalar":
+		var sample [2]any
+		if err := json.Unmarshal(out.Data.Result, &sample); err != nil {
+			return 0, fmt.Errorf("decoding prometheus response: %w", err)
+		}
+		return sampleValue(sample)
+	case "vector":
+		var series []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(out.Data.Result, &series); err != nil {
+			return 0, fmt.Errorf("decoding prometheus response: %w", err)
+		}
+		if len(series) == 0 {
+			return 0, fmt.Errorf("query %q returned no series", expr)
+		}
+		if len(series) > 1 {
+			return 0, fmt.Errorf("query %q returned %d series; aggregate it down to one", expr, len(series))
+		}
+		return sampleValue(series[0].Value)
+	default:
+		return 0, fmt.Errorf("query %q returned unsupported result type %q", expr, out.Data.ResultType)
+	}
+}
+
+func sampleValue(sample [2]any) (float64, error) {
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value %v", sample[1])
+	}
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,69 @@
+// Package push sends campaign metrics to a Prometheus Pushgateway in
+// the plain text exposition format — enough for CronJob runs that have
+// no scrapeable endpoint of their own.
+package push
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+)
+
+// Campaign pushes the outcome of a finished campaign, grouped under
+// job="crt_campaign" so successive runs replace each other.
+func Campaign(ctx context.Context, gatewayURL string, cp *campaign.Campaign, started, finished time.Time) error {
+	var b strings.Builder
+
+	b.WriteString("# TYPE crt_campaign_info gauge\n")
+	fmt.Fprintf(&b, "crt_campaign_info{campaign_id=%q} 1\n", cp.ID)
+
+	b.WriteString("# TYPE crt_campaign_duration_seconds gauge\n")
+	fmt.Fprintf(&b, "crt_campaign_duration_seconds %f\n", finished.Sub(started).Seconds())
+
+	b.WriteString("# TYPE crt_pods_restarted_total gauge\n")
+	b.WriteString("# TYPE crt_clusters_failed_total gauge\n")
+	b.WriteString("# TYPE crt_pod_restart_seconds gauge\n")
+	b.WriteString("# TYPE crt_cluster_restart_seconds gauge\n")
+
+	pods, failed := 0, 0
+	for _, res := range cp.Results {
+		labels := fmt.Sprintf(`namespace=%q,cluster=%q`, res.Namespace, res.Name)
+		if res.Tenant != "" {
+			labels += fmt.Sprintf(`,tenant=%q`, res.Tenant)
+		}
+		switch res.Status {
+		case "failed":
+			failed++
+		case "succeeded":
+			fmt.Fprintf(&b, "crt_cluster_restart_seconds{%s} %f\n", labels, res.Finished.Sub(res.Started).Seconds())
+		}
+		for _, p := range res.Pods {
+			if p.Error == "" {
+				pods++
+				fmt.Fprintf(&b, "crt_pod_restart_seconds{%s,pod=%q} %f\n", labels, p.Pod, p.Finished.Sub(p.Started).Seconds())
+			}
+		}
+	}
+	fmt.Fprintf(&b, "crt_pods_restarted_total %d\n", pods)
+	fmt.Fprintf(&b, "crt_clusters_failed_total %d\n", failed)
+
+	url := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/crt_campaign"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, strings.NewReader(b.String()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing metrics: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pushing metrics: pushgateway returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
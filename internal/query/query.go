@@ -0,0 +1,349 @@
+// Package query implements the small expression language behind
+// `crt find -where`: field comparisons over raw CR objects combined
+// with && and ||, e.g.
+//
+//	spec.cluster.version < 5.6 && health != GREEN
+//
+// Paths are dotted and rooted at the CR object; health, name and
+// namespace are virtual fields (health goes through the configured
+// health mapping). Values compare segment-wise when both sides look
+// like dotted versions, numerically when both are numbers, and as
+// strings otherwise. A bare path is its own truthiness test and ! is
+// negation, so policies read like "nodes >= 3 && !spec.backups.disabled".
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+)
+
+// Expr is one parsed expression.
+type Expr struct {
+	root node
+	src  string
+}
+
+// String returns the source the expression was parsed from.
+func (e *Expr) String() string { return e.src }
+
+// Parse compiles an expression.
+func Parse(s string) (*Expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek())
+	}
+	return &Expr{root: root, src: s}, nil
+}
+
+// Match reports whether the CR object satisfies the expression.
+func (e *Expr) Match(obj map[string]any) bool {
+	return e.root.eval(obj)
+}
+
+type node interface {
+	eval(obj map[string]any) bool
+}
+
+type binary struct {
+	or          bool
+	left, right node
+}
+
+func (b binary) eval(obj map[string]any) bool {
+	if b.or {
+		return b.left.eval(obj) || b.right.eval(obj)
+	}
+	return b.left.eval(obj) && b.right.eval(obj)
+}
+
+type not struct {
+	inner node
+}
+
+func (n not) eval(obj map[string]any) bool { return !n.inner.eval(obj) }
+
+// truthy is a bare path used as a condition: present-and-true for
+// bools, non-zero for numbers, non-empty for strings.
+type truthy struct {
+	path string
+}
+
+func (t truthy) eval(obj map[string]any) bool {
+	switch v := resolve(obj, t.path).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+type cmp struct {
+	path, op, value string
+}
+
+func (c cmp) eval(obj map[string]any) bool {
+	left := resolve(obj, c.path)
+	if left == nil {
+		// A missing field differs from every value and equals none.
+		return c.op == "!="
+	}
+	return holds(order(fmt.Sprint(left), c.value), c.op)
+}
+
+// resolve walks the dotted path, with the virtual fields mapped onto
+// their real locations.
+func resolve(obj map[string]any, path string) any {
+	switch path {
+	case "health":
+		return cratedb.HealthOf(obj)
+	case "name":
+		path = "metadata.name"
+	case "namespace":
+		path = "metadata.namespace"
+	}
+	cur := any(obj)
+	for _, f := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[f]
+	}
+	return cur
+}
+
+// order compares two rendered values: -1, 0 or 1.
+func order(left, right string) int {
+	if looksVersion(left) && looksVersion(right) {
+		return compareVersions(left, right)
+	}
+	lf, el := strconv.ParseFloat(left, 64)
+	rf, er := strconv.ParseFloat(right, 64)
+	if el == nil && er == nil {
+		switch {
+		case lf < rf:
+			return -1
+		case lf > rf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(left, right)
+}
+
+func holds(c int, op string) bool {
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default: // ">="
+		return c >= 0
+	}
+}
+
+// looksVersion reports whether s is dotted digits like 5.6 or 5.10.2.
+// Such values compare per segment, so 5.10 sorts above 5.6 where a
+// float comparison would get it wrong.
+func looksVersion(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, p := range parts {
+		if p == "" {
+			return false
+		}
+		for i := 0; i < len(p); i++ {
+			if p[i] < '0' || p[i] > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var ai, bi int
+		if i < len(as) {
+			ai, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bi, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		}
+	}
+	return 0
+}
+
+type parser struct {
+	toks []string
+	pos  int
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.toks) }
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{left: left, right: right}
+	}
+	return left, nil
+}
+
+func isOperator(t string) bool {
+	switch t {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseTerm() (node, error) {
+	if p.peek() == "!" {
+		p.next()
+		inner, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return not{inner: inner}, nil
+	}
+	if p.peek() == "(" {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+	path := p.next()
+	if path == "" || path == ")" || isOperator(path) {
+		return nil, fmt.Errorf("expected a field path, got %q", path)
+	}
+	if !isOperator(p.peek()) {
+		return truthy{path: path}, nil
+	}
+	op := p.next()
+	value := p.next()
+	if value == "(" || value == ")" || value == "&&" || value == "||" || isOperator(value) {
+		return nil, fmt.Errorf("expected a value after %s %s, got %q", path, op, value)
+	}
+	return cmp{path: path, op: op, value: value}, nil
+}
+
+// lex splits the expression into parentheses, operators, quoted
+// strings and barewords.
+func lex(s string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, string(c))
+			i++
+		case strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||") ||
+			strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!=") ||
+			strings.HasPrefix(s[i:], "<=") || strings.HasPrefix(s[i:], ">="):
+			toks = append(toks, s[i:i+2])
+			i += 2
+		case c == '<' || c == '>':
+			toks = append(toks, string(c))
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %q", s[i:])
+			}
+			toks = append(toks, s[i+1:i+1+end])
+			i += end + 2
+		case c == '!':
+			toks = append(toks, "!")
+			i++
+		case c == '=' || c == '&' || c == '|':
+			return nil, fmt.Errorf("unexpected %q", string(c))
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t()<>=!&|'\"", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return toks, nil
+}
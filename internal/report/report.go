@@ -0,0 +1,52 @@
+// Package report serializes campaign outcomes for archival.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+)
+
+// Report is the JSON document written after a campaign.
+type Report struct {
+	CampaignID string                   `json:"campaign_id"`
+	Reason     string                   `json:"reason,omitempty"`
+	Started    time.Time                `json:"started"`
+	Finished   time.Time                `json:"finished"`
+	Clusters   []campaign.ClusterResult `json:"clusters"`
+}
+
+// FromCampaign builds a report from a finished campaign.
+func FromCampaign(cp *campaign.Campaign, started, finished time.Time) *Report {
+	return &Report{
+		CampaignID: cp.ID,
+		Reason:     cp.Options.Reason,
+		Started:    started,
+		Finished:   finished,
+		Clusters:   cp.Results,
+	}
+}
+
+// JSON renders the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// WriteFile writes the report as indented JSON to path.
+func (r *Report) WriteFile(path string) error {
+	b, err := r.JSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+const resultsSchema = `CREATE TABLE IF NOT EXISTS %s (
+  campaign_id TEXT,
+  ts TIMESTAMP WITH TIME ZONE,
+  namespace TEXT,
+  cluster TEXT,
+  pod TEXT,
+  kind TEXT,
+  seconds DOUBLE,
+  status TEXT,
+  error TEXT,
+  reason TEXT
+)`
+
+// WriteToCrateDB inserts the campaign's pod and cluster records into a
+// table on the given CrateDB cluster, creating the table on first use.
+// This gives fleet owners a queryable audit trail next to the
+// databases being managed.
+func WriteToCrateDB(ctx context.Context, k *kube.Client, namespace, cluster, table string, cp *campaign.Campaign) error {
+	sql, err := cratesql.Connect(ctx, k, namespace, cluster)
+	if err != nil {
+		return err
+	}
+	defer sql.Close()
+
+	if _, err := sql.Exec(ctx, fmt.Sprintf(resultsSchema, table)); err != nil {
+		return fmt.Errorf("creating results table: %w", err)
+	}
+	// Tables created before the reason column existed need it added;
+	// the ALTER fails harmlessly when the column is already there.
+	sql.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN reason TEXT", table))
+
+	var rows [][]any
+	for _, res := range cp.Results {
+		for _, p := range res.Pods {
+			status := "succeeded"
+			if p.Error != "" {
+				status = "failed"
+			}
+			rows = append(rows, []any{
+				cp.ID, p.Finished.UnixMilli(), res.Namespace, res.Name,
+				p.Pod, "pod", p.Finished.Sub(p.Started).Seconds(), status, p.Error,
+				cp.Options.Reason,
+			})
+		}
+		rows = append(rows, []any{
+			cp.ID, res.Finished.UnixMilli(), res.Namespace, res.Name,
+			"", "cluster", res.Finished.Sub(res.Started).Seconds(), res.Status, res.Error,
+			cp.Options.Reason,
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (campaign_id, ts, namespace, cluster, pod, kind, seconds, status, error, reason) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", table)
+	if _, err := sql.ExecBulk(ctx, stmt, rows); err != nil {
+		return fmt.Errorf("inserting results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package statuspage creates and resolves maintenance incidents on an
+// Atlassian Statuspage (or an API-compatible service) around restart
+// campaigns, so customer-facing status reflects planned work without a
+// human remembering to post it.
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client talks to one Statuspage page.
+type Client struct {
+	BaseURL string // API base, e.g. https://api.statuspage.io/v1
+	PageID  string
+	Token   string
+
+	http *http.Client
+}
+
+// New builds a client for one page.
+func New(baseURL, pageID, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		PageID:  pageID,
+		Token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// StartMaintenance opens an in-progress maintenance incident and
+// returns its ID for the later resolve.
+func (c *Client) StartMaintenance(ctx context.Context, name, body string) (string, error) {
+	payload := map[string]any{
+		"incident": map[string]any{
+			"name":            name,
+			"status":          "in_progress",
+			"impact_override": "maintenance",
+			"body":            body,
+		},
+	}
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/incidents", payload, &out); err != nil {
+		return "", fmt.Errorf("creating maintenance: %w", err)
+	}
+	return out.ID, nil
+}
+
+// Resolve completes the maintenance incident with a closing note.
+func (c *Client) Resolve(ctx context.Context, id, body string) error {
+	payload := map[string]any{
+		"incident": map[string]any{
+			"status": "completed",
+			"body":   body,
+		},
+	}
+	if err := c.do(ctx, http.MethodPatch, "/incidents/"+id, payload, nil); err != nil {
+		return fmt.Errorf("resolving maintenance: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, payload, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	url := c.BaseURL + "/pages/" + c.PageID + path
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "OAuth "+c.Token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("statuspage returned HTTP %d", resp.StatusCode)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
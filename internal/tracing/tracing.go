@@ -0,0 +1,203 @@
+// Package tracing is a minimal OpenTelemetry span exporter. Spans are
+// collected in memory and flushed as OTLP/HTTP JSON to a collector at
+// the end of a run, which is enough to see where a long campaign
+// spends its time in Tempo or Jaeger without pulling in the otel SDK.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Attr is one span attribute. Values may be string, bool, or any Go
+// integer; everything else is stringified.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String builds a string attribute.
+func String(key, value string) Attr { return Attr{Key: key, Value: value} }
+
+// Int builds an integer attribute.
+func Int(key string, value int) Attr { return Attr{Key: key, Value: value} }
+
+// Span is one timed operation. A nil Span is valid and inert, so
+// callers never need to check whether tracing is enabled.
+type Span struct {
+	tracer   *Tracer
+	spanID   string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attr
+	err      error
+}
+
+// Tracer collects spans for a single trace. A nil Tracer is valid and
+// produces no spans.
+type Tracer struct {
+	endpoint string
+	traceID  string
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// New returns a Tracer posting to endpoint (an OTLP/HTTP base URL such
+// as http://collector:4318), or nil when endpoint is empty.
+func New(endpoint string) *Tracer {
+	if endpoint == "" {
+		return nil
+	}
+	return &Tracer{endpoint: endpoint, traceID: randomHex(16)}
+}
+
+// TraceID returns the trace ID, or "" on a nil tracer.
+func (t *Tracer) TraceID() string {
+	if t == nil {
+		return ""
+	}
+	return t.traceID
+}
+
+// Start begins a span under parent (nil for a root span).
+func (t *Tracer) Start(parent *Span, name string, attrs ...Attr) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{
+		tracer: t,
+		spanID: randomHex(8),
+		name:   name,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent != nil {
+		s.parentID = parent.spanID
+	}
+	return s
+}
+
+// End finishes the span, recording err as its status if non-nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+}
+
+// SetAttr adds an attribute to an in-flight span.
+func (s *Span) SetAttr(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// Flush posts all collected spans to the collector. Safe on nil.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []any{otlpAttr(Attr{"service.name", "crt"})},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "crt"},
+				"spans": otlpSpans(t.traceID, spans),
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting traces: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("exporting traces: collector returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func otlpSpans(traceID string, spans []*Span) []any {
+	out := make([]any, 0, len(spans))
+	for _, s := range spans {
+		attrs := make([]any, 0, len(s.attrs))
+		for _, a := range s.attrs {
+			attrs = append(attrs, otlpAttr(a))
+		}
+		span := map[string]any{
+			"traceId":           traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        attrs,
+		}
+		if s.parentID != "" {
+			span["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			span["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		out = append(out, span)
+	}
+	return out
+}
+
+func otlpAttr(a Attr) map[string]any {
+	var v map[string]any
+	switch val := a.Value.(type) {
+	case string:
+		v = map[string]any{"stringValue": val}
+	case bool:
+		v = map[string]any{"boolValue": val}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(val)}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(val, 10)}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(val)}
+	}
+	return map[string]any{"key": a.Key, "value": v}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,130 @@
+// Package unstructured provides small helpers for digging values out
+// of JSON-decoded Kubernetes objects (map[string]any).
+//
+// The plain accessors return zero values for both absent fields and
+// fields of the wrong type. The E variants distinguish the two: absence
+// is a zero value with a nil error (optional fields are normal), a
+// type mismatch is an error, because that means the object's schema is
+// not what the tool was built against.
+package unstructured
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NestedMap walks fields and returns the map at the end of the path,
+// or nil if any step is missing or of the wrong type.
+func NestedMap(obj map[string]any, fields ...string) map[string]any {
+	m, _ := NestedMapE(obj, fields...)
+	return m
+}
+
+// NestedMapE is NestedMap reporting type mismatches along the path.
+func NestedMapE(obj map[string]any, fields ...string) (map[string]any, error) {
+	cur := obj
+	for i, f := range fields {
+		v, ok := cur[f]
+		if !ok {
+			return nil, nil
+		}
+		next, ok := v.(map[string]any)
+		if !ok {
+			return nil, typeError(fields[:i+1], "object", v)
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// NestedString returns the string at the path, or "".
+func NestedString(obj map[string]any, fields ...string) string {
+	s, _ := NestedStringE(obj, fields...)
+	return s
+}
+
+// NestedStringE is NestedString reporting type mismatches.
+func NestedStringE(obj map[string]any, fields ...string) (string, error) {
+	v, err := nestedValue(obj, fields...)
+	if err != nil || v == nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", typeError(fields, "string", v)
+	}
+	return s, nil
+}
+
+// NestedInt64 returns the number at the path as int64, or 0. JSON
+// decoding yields float64 for all numbers.
+func NestedInt64(obj map[string]any, fields ...string) int64 {
+	n, _ := NestedInt64E(obj, fields...)
+	return n
+}
+
+// NestedInt64E is NestedInt64 reporting type mismatches.
+func NestedInt64E(obj map[string]any, fields ...string) (int64, error) {
+	v, err := nestedValue(obj, fields...)
+	if err != nil || v == nil {
+		return 0, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return 0, typeError(fields, "number", v)
+	}
+	return int64(f), nil
+}
+
+// NestedBool returns the bool at the path, or false.
+func NestedBool(obj map[string]any, fields ...string) bool {
+	b, _ := NestedBoolE(obj, fields...)
+	return b
+}
+
+// NestedBoolE is NestedBool reporting type mismatches.
+func NestedBoolE(obj map[string]any, fields ...string) (bool, error) {
+	v, err := nestedValue(obj, fields...)
+	if err != nil || v == nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, typeError(fields, "bool", v)
+	}
+	return b, nil
+}
+
+// NestedSlice returns the slice at the path, or nil.
+func NestedSlice(obj map[string]any, fields ...string) []any {
+	s, _ := NestedSliceE(obj, fields...)
+	return s
+}
+
+// NestedSliceE is NestedSlice reporting type mismatches.
+func NestedSliceE(obj map[string]any, fields ...string) ([]any, error) {
+	v, err := nestedValue(obj, fields...)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	s, ok := v.([]any)
+	if !ok {
+		return nil, typeError(fields, "array", v)
+	}
+	return s, nil
+}
+
+func nestedValue(obj map[string]any, fields ...string) (any, error) {
+	if len(fields) == 0 {
+		return obj, nil
+	}
+	parent, err := NestedMapE(obj, fields[:len(fields)-1]...)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	return parent[fields[len(fields)-1]], nil
+}
+
+func typeError(fields []string, want string, got any) error {
+	return fmt.Errorf("field %s: expected %s, got %T", strings.Join(fields, "."), want, got)
+}
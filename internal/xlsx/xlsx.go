@@ -0,0 +1,185 @@
+// Package xlsx writes minimal XLSX workbooks with the standard
+// library: a zip of hand-rendered OOXML parts, inline strings only,
+// one bold header row per sheet. It covers what the fleet report
+// needs and is not a general spreadsheet library.
+package xlsx
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Workbook is a set of sheets, written in insertion order.
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// Sheet is one worksheet; its first row renders bold.
+type Sheet struct {
+	name string
+	rows [][]string
+}
+
+// New returns an empty workbook.
+func New() *Workbook { return &Workbook{} }
+
+// AddSheet appends a sheet. Names are clamped to Excel's rules (31
+// chars, no []:*?/\ characters).
+func (w *Workbook) AddSheet(name string) *Sheet {
+	s := &Sheet{name: sheetName(name, len(w.sheets)+1)}
+	w.sheets = append(w.sheets, s)
+	return s
+}
+
+// AddRow appends one row of cells.
+func (s *Sheet) AddRow(cells ...string) {
+	s.rows = append(s.rows, cells)
+}
+
+// WriteFile writes the workbook to path.
+func (w *Workbook) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write renders the workbook as a zip archive.
+func (w *Workbook) Write(out io.Writer) error {
+	if len(w.sheets) == 0 {
+		return fmt.Errorf("workbook has no sheets")
+	}
+	z := zip.NewWriter(out)
+	parts := map[string]string{
+		"[Content_Types].xml":        w.contentTypes(),
+		"_rels/.rels":                rels,
+		"xl/workbook.xml":            w.workbook(),
+		"xl/_rels/workbook.xml.rels": w.workbookRels(),
+		"xl/styles.xml":              styles,
+	}
+	for i, s := range w.sheets {
+		parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)] = s.render()
+	}
+	for name, content := range parts {
+		f, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xmlHeader+content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+	rels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	// Two fonts (regular, bold) and two cell formats; style s="1" is
+	// the bold one used for header rows.
+	styles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font/><font><b/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+)
+
+func (w *Workbook) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Workbook) workbook() string {
+	var b strings.Builder
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range w.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Workbook) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func (s *Sheet) render() string {
+	var b strings.Builder
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			style := ""
+			if r == 0 {
+				style = ` s="1"`
+			}
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"%s><is><t>%s</t></is></c>`,
+				colRef(c), r+1, style, escape(cell))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// colRef renders a zero-based column index as A, B, ... Z, AA, AB.
+func colRef(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+func sheetName(name string, ordinal int) string {
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", ordinal)
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	return name
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;",
+	).Replace(s)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "inventory",
+		summary: "list cratedbs across every kubeconfig context, with local caching",
+		run:     runInventory,
+	})
+}
+
+// inventoryCache is the on-disk snapshot of the last full sweep.
+type inventoryCache struct {
+	Taken   time.Time        `json:"taken"`
+	Entries []inventoryEntry `json:"entries"`
+}
+
+type inventoryEntry struct {
+	Context   string `json:"context"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Nodes     int64  `json:"nodes"`
+	Health    string `json:"health"`
+}
+
+func inventoryCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".crt-inventory.json"
+	}
+	return filepath.Join(home, ".crt", "inventory.json")
+}
+
+func runInventory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("inventory", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	refresh := fs.Bool("refresh", false, "force live queries even if the cache is fresh")
+	staleOK := fs.Bool("stale-ok", false, "serve the cache regardless of age, never query live")
+	ttl := fs.Duration("ttl", 15*time.Minute, "how long cached results stay fresh")
+	cacheFile := fs.String("cache-file", inventoryCachePath(), "inventory cache location")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	cache, cacheErr := readInventoryCache(*cacheFile)
+	age := time.Duration(0)
+	if cacheErr == nil {
+		age = time.Since(cache.Taken)
+	}
+	useCache := cacheErr == nil && !*refresh && (*staleOK || age < *ttl)
+	if *staleOK && cacheErr != nil {
+		return fmt.Errorf("no usable inventory cache at %s: %w", *cacheFile, cacheErr)
+	}
+
+	entries := cache.Entries
+	if !useCache {
+		var err error
+		if entries, err = sweepInventory(ctx, &g); err != nil {
+			return err
+		}
+		if err := writeInventoryCache(*cacheFile, inventoryCache{Taken: time.Now(), Entries: entries}); err != nil {
+			cli.Warnf("%v", err)
+		}
+		age = 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT\tNAMESPACE\tNAME\tVERSION\tNODES\tHEALTH")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			e.Context, e.Namespace, e.Name, e.Version, e.Nodes, e.Health)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if useCache {
+		cli.Infof("from cache, %s old (use -refresh for live data)", age.Round(time.Second))
+	}
+	return nil
+}
+
+// sweepInventory lists cratedbs in every kubeconfig context. A context
+// that cannot be reached is reported and skipped so one dead cluster
+// does not hide the rest of the fleet.
+func sweepInventory(ctx context.Context, g *cli.GlobalFlags) ([]inventoryEntry, error) {
+	contexts, err := kube.Contexts(g.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+	var entries []inventoryEntry
+	for _, kctx := range contexts {
+		cfg, err := kube.LoadConfig(kctx, g.Kubeconfig)
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		c, err := kube.NewClient(cfg)
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		clusters, err := cratedb.List(ctx, c, "")
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		for _, cl := range clusters {
+			entries = append(entries, inventoryEntry{
+				Context:   kctx,
+				Namespace: cl.Namespace,
+				Name:      cl.Name,
+				Version:   cl.Version(),
+				Nodes:     cl.DataNodes(),
+				Health:    cl.Health(),
+			})
+		}
+	}
+	return entries, nil
+}
+
+func readInventoryCache(path string) (inventoryCache, error) {
+	var cache inventoryCache
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cache, err
+	}
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return cache, fmt.Errorf("parsing inventory cache: %w", err)
+	}
+	return cache, nil
+}
+
+func writeInventoryCache(path string, cache inventoryCache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+const (
+	leaseName     = "crt-serve"
+	leaseDuration = 30 * time.Second
+	leaseRenew    = 10 * time.Second
+)
+
+// acquireLease blocks until this process holds the serve Lease in ns,
+// then keeps renewing it in the background. It lets the generated
+// Deployment run more than one replica without duplicate restarts.
+func acquireLease(ctx context.Context, c *kube.Client, ns string) error {
+	identity, _ := os.Hostname()
+	if identity == "" {
+		identity = fmt.Sprintf("crt-%d", os.Getpid())
+	}
+	path := kube.ResourcePath("coordination.k8s.io/v1", ns, "leases", leaseName)
+
+	for {
+		held, err := tryLease(ctx, c, path, ns, identity)
+		if err != nil {
+			return err
+		}
+		if held {
+			break
+		}
+		cli.Debugf("lease %s held elsewhere, waiting", leaseName)
+		if err := cli.Sleep(ctx, leaseRenew); err != nil {
+			return err
+		}
+	}
+	cli.Infof("acquired leadership lease %s/%s as %s", ns, leaseName, identity)
+
+	go func() {
+		for {
+			if err := cli.Sleep(ctx, leaseRenew); err != nil {
+				return
+			}
+			if _, err := tryLease(ctx, c, path, ns, identity); err != nil {
+				cli.Warnf("renewing lease: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// tryLease acquires or renews the lease when it is free, expired, or
+// already ours. Server-side apply keeps the write attributable and
+// last-writer-wins is acceptable for this tool's stakes.
+func tryLease(ctx context.Context, c *kube.Client, path, ns, identity string) (bool, error) {
+	var lease map[string]any
+	err := c.Get(ctx, path, &lease)
+	if err != nil && !kube.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		holder := unstructured.NestedString(lease, "spec", "holderIdentity")
+		renewed, _ := time.Parse("2006-01-02T15:04:05.000000Z07:00",
+			unstructured.NestedString(lease, "spec", "renewTime"))
+		if holder != identity && holder != "" && time.Since(renewed) < leaseDuration {
+			return false, nil
+		}
+	}
+	apply := map[string]any{
+		"apiVersion": "coordination.k8s.io/v1",
+		"kind":       "Lease",
+		"metadata":   map[string]any{"name": leaseName, "namespace": ns},
+		"spec": map[string]any{
+			"holderIdentity":       identity,
+			"leaseDurationSeconds": int(leaseDuration.Seconds()),
+			"renewTime":            time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		},
+	}
+	if err := c.Apply(ctx, path, apply); err != nil {
+		return false, fmt.Errorf("acquiring lease: %w", err)
+	}
+	return true, nil
+}
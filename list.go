@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/certs"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "list",
+		summary: "list CrateDB clusters and their health",
+		run:     runList,
+	})
+}
+
+func runList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	showCerts := fs.Bool("certs", false, "include a certificate expiry column")
+	probeHTTP := fs.Bool("probe-http", false, "probe every pod's HTTP admin port and include a PROBE column")
+	showUptime := fs.Bool("uptime", false, "include an UPTIME column with the youngest pod's uptime")
+	sortBy := fs.String("sort-by", "", "sort rows by: age (oldest cluster first; default: namespace/name)")
+	warnExpiry := fs.String("warn-expiry", "", "exit non-zero if any cert expires within this window, e.g. 30d (implies -certs)")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	var warnWindow time.Duration
+	if *warnExpiry != "" {
+		var err error
+		if warnWindow, err = parseDays(*warnExpiry); err != nil {
+			return err
+		}
+		*showCerts = true
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	clusters, err := cratedb.List(ctx, c, g.ResolveNamespace(c))
+	if err != nil {
+		return err
+	}
+	switch *sortBy {
+	case "":
+	case "age":
+		sort.SliceStable(clusters, func(i, j int) bool {
+			return clusters[i].Created().Before(clusters[j].Created())
+		})
+	default:
+		return fmt.Errorf("invalid -sort-by %q (want age)", *sortBy)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tenantKeys := cfg.TenantKeys()
+	cratedb.SetHealthMapping(cfg.HealthPath, cfg.GreenValues)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	header := "NAMESPACE\tNAME\tTENANT\tVERSION\tNODES\tHEALTH\tAGE"
+	if *showUptime {
+		header += "\tUPTIME"
+	}
+	if *showCerts {
+		header += "\tCERT-EXPIRY"
+	}
+	if *probeHTTP {
+		header += "\tPROBE"
+	}
+	fmt.Fprintln(w, header)
+	expiring := 0
+	for _, cl := range clusters {
+		age := "-"
+		if created := cl.Created(); !created.IsZero() {
+			age = fmtAge(time.Since(created))
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%d\t%s\t%s",
+			cl.Namespace, cl.Name, orDash(cl.Tenant(tenantKeys), "-"), cl.Version(), cl.DataNodes(), cl.Health(), age)
+		if *showUptime {
+			line += "\t" + uptimeColumn(ctx, c, cl)
+		}
+		if *showCerts {
+			col := "-"
+			infos, err := certs.ForCluster(ctx, c, cl)
+			if err != nil {
+				cli.Debugf("%v", err)
+			} else if soonest := certs.SoonestExpiry(infos); !soonest.IsZero() {
+				days := int(time.Until(soonest).Hours() / 24)
+				col = fmt.Sprintf("%dd", days)
+				if warnWindow > 0 && time.Until(soonest) < warnWindow {
+					col += " !"
+					expiring++
+				}
+			}
+			line += "\t" + col
+		}
+		if *probeHTTP {
+			line += "\t" + probeColumn(ctx, c, cl)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if expiring > 0 {
+		return fmt.Errorf("%d cluster(s) have certificates expiring within %s", expiring, *warnExpiry)
+	}
+	return nil
+}
+
+// uptimeColumn reports the youngest pod's uptime: a cluster whose
+// value is suspiciously low was just restarted or re-created, however
+// old its CR is.
+func uptimeColumn(ctx context.Context, c *kube.Client, cl cratedb.Cluster) string {
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		cli.Debugf("%v", err)
+		return "-"
+	}
+	var youngest time.Time
+	for _, pod := range pods {
+		started, err := time.Parse(time.RFC3339, unstructured.NestedString(pod, "status", "startTime"))
+		if err != nil {
+			continue
+		}
+		if started.After(youngest) {
+			youngest = started
+		}
+	}
+	if youngest.IsZero() {
+		return "-"
+	}
+	return fmtAge(time.Since(youngest))
+}
+
+// fmtAge renders a duration kubectl-style: whole days above 48h,
+// otherwise the largest fitting unit.
+func fmtAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+// probeColumn probes every pod's HTTP admin port and summarizes the
+// result, e.g. "3/3 ok (5.7.2)". Readiness can lie about the admin
+// port; the probe goes through a real tunnel like clients do.
+func probeColumn(ctx context.Context, c *kube.Client, cl cratedb.Cluster) string {
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		cli.Debugf("%v", err)
+		return "-"
+	}
+	ok := 0
+	version := ""
+	for _, pod := range pods {
+		name := unstructured.NestedString(pod, "metadata", "name")
+		v, err := cratesql.ProbePod(ctx, c, cl.Namespace, name)
+		if err != nil {
+			cli.Debugf("%s/%s: %v", cl.Namespace, name, err)
+			continue
+		}
+		ok++
+		version = v
+	}
+	col := fmt.Sprintf("%d/%d ok", ok, len(pods))
+	if ok == len(pods) && ok > 0 && version != "" {
+		col += " (" + version + ")"
+	}
+	return col
+}
+
+// parseDays parses durations like "30d" or anything
+// time.ParseDuration accepts.
+func parseDays(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,73 @@
+// crt is a small operations CLI for CrateDB clusters running on
+// Kubernetes under the crate-operator. It lists clusters across
+// namespaces and performs rolling restarts that wait for the cluster
+// to report GREEN health between pods.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// progName is how the binary was invoked. When installed as
+// kubectl-crate (krew-style), messages and usage read "kubectl crate"
+// so plugin users see consistent output.
+var progName = "crt"
+
+func init() {
+	base := filepath.Base(os.Args[0])
+	if strings.HasPrefix(base, "kubectl-") {
+		progName = "kubectl " + strings.ReplaceAll(strings.TrimPrefix(base, "kubectl-"), "-", " ")
+	}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands []command
+
+func register(c command) {
+	commands = append(commands, c)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", progName)
+	sorted := make([]command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, c := range sorted {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(ctx, os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", progName, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", progName, os.Args[1])
+	usage()
+	os.Exit(2)
+}
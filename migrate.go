@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "migrate-storage",
+		summary: "move a cluster to a new StorageClass pod by pod",
+		run:     runMigrateStorage,
+	})
+}
+
+func runMigrateStorage(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	expectSC := fs.String("expect-storage-class", "", "fail unless the StatefulSet template uses this StorageClass")
+	yes := fs.Bool("yes", false, "do not prompt before deleting pods and PVCs")
+	podTimeout := fs.Duration("pod-timeout", 20*time.Minute, "max wait for a pod to return Ready on new storage")
+	recoveryTimeout := fs.Duration("recovery-timeout", 2*time.Hour, "max wait for shard recovery after each pod")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt migrate-storage [namespace/]name")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+	if h := cl.Health(); h != cratedb.HealthGreen {
+		return fmt.Errorf("cluster is %s; storage migration requires GREEN", h)
+	}
+
+	if *expectSC != "" {
+		if err := checkTemplateStorageClass(ctx, c, cl, *expectSC); err != nil {
+			return err
+		}
+	}
+
+	sql, err := cratesql.Connect(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+	defer sql.Close()
+
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	for i := len(pods) - 1; i >= 0; i-- {
+		pod := pods[i]
+		podName := unstructured.NestedString(pod, "metadata", "name")
+		pvcName := podPVCName(pod)
+		if pvcName == "" {
+			return fmt.Errorf("pod %s: could not determine data PVC", podName)
+		}
+		if !*yes && !cli.Confirm(fmt.Sprintf("delete pod %s and PVC %s", podName, pvcName)) {
+			return fmt.Errorf("aborted at pod %s", podName)
+		}
+
+		cli.Infof("%s: decommissioning node for pod %s", name, podName)
+		if err := decommissionNode(ctx, sql, podName); err != nil {
+			cli.Warnf("decommission: %v (continuing; shards will recover after delete)", err)
+		}
+
+		cli.Infof("%s: deleting PVC %s and pod %s", name, pvcName, podName)
+		if err := c.Delete(ctx, kube.ResourcePath("v1", ns, "persistentvolumeclaims", pvcName)); err != nil {
+			return fmt.Errorf("deleting PVC %s: %w", pvcName, err)
+		}
+		if err := c.Delete(ctx, kube.ResourcePath("v1", ns, "pods", podName)); err != nil {
+			return fmt.Errorf("deleting pod %s: %w", podName, err)
+		}
+
+		if err := waitPodReadyByName(ctx, c, ns, podName, *podTimeout); err != nil {
+			return fmt.Errorf("pod %s: %w", podName, err)
+		}
+		cli.Infof("%s: pod %s back on new storage, waiting for shard recovery", name, podName)
+		if err := waitShardRecovery(ctx, sql, c, ns, name, *recoveryTimeout); err != nil {
+			return fmt.Errorf("after pod %s: %w", podName, err)
+		}
+		cli.Infof("%s: recovery complete after %s", name, podName)
+	}
+	cli.Infof("%s/%s: storage migration finished", ns, name)
+	return nil
+}
+
+func checkTemplateStorageClass(ctx context.Context, c *kube.Client, cl cratedb.Cluster, want string) error {
+	q := url.Values{"labelSelector": []string{cl.PodSelector()}}
+	stss, err := c.List(ctx, kube.ResourcePath("apps/v1", cl.Namespace, "statefulsets", ""), q)
+	if err != nil {
+		return fmt.Errorf("listing statefulsets: %w", err)
+	}
+	for _, sts := range stss {
+		for _, t := range unstructured.NestedSlice(sts, "spec", "volumeClaimTemplates") {
+			tmpl, ok := t.(map[string]any)
+			if !ok {
+				continue
+			}
+			sc := unstructured.NestedString(tmpl, "spec", "storageClassName")
+			if sc != want {
+				return fmt.Errorf("statefulset %s volumeClaimTemplate uses StorageClass %q, want %q; update the operator config first",
+					unstructured.NestedString(sts, "metadata", "name"), sc, want)
+			}
+		}
+	}
+	return nil
+}
+
+// podPVCName finds the PVC backing the pod's data volume.
+func podPVCName(pod map[string]any) string {
+	for _, v := range unstructured.NestedSlice(pod, "spec", "volumes") {
+		vol, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if claim := unstructured.NestedString(vol, "persistentVolumeClaim", "claimName"); claim != "" {
+			return claim
+		}
+	}
+	return ""
+}
+
+// decommissionNode gracefully moves shards off the crate node running
+// in the named pod before it is deleted.
+func decommissionNode(ctx context.Context, sql *cratesql.Client, podName string) error {
+	res, err := sql.Exec(ctx, "SELECT id FROM sys.nodes WHERE hostname = ?", podName)
+	if err != nil {
+		return err
+	}
+	if len(res.Rows) == 0 {
+		return fmt.Errorf("no sys.nodes entry with hostname %s", podName)
+	}
+	id, _ := res.Rows[0][0].(string)
+	_, err = sql.Exec(ctx, fmt.Sprintf("ALTER CLUSTER DECOMMISSION '%s'", id))
+	return err
+}
+
+func waitPodReadyByName(ctx context.Context, c *kube.Client, ns, podName string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	path := kube.ResourcePath("v1", ns, "pods", podName)
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for Ready", timeout)
+		}
+		var pod map[string]any
+		err := c.Get(ctx, path, &pod)
+		if err == nil && cratedb.PodReady(pod) {
+			return nil
+		}
+		if err != nil && !kube.IsNotFound(err) {
+			return err
+		}
+		if err := cli.Sleep(ctx, kube.WaitBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+// waitShardRecovery blocks until no shards are initializing or
+// relocating and the CR reports GREEN.
+func waitShardRecovery(ctx context.Context, sql *cratesql.Client, c *kube.Client, ns, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for shard recovery", timeout)
+		}
+		res, err := sql.Exec(ctx, "SELECT count(*) FROM sys.shards WHERE routing_state IN ('INITIALIZING', 'RELOCATING')")
+		if err != nil {
+			return err
+		}
+		var busy float64
+		if len(res.Rows) > 0 {
+			busy, _ = res.Rows[0][0].(float64)
+		}
+		if busy == 0 {
+			cl, err := cratedb.Get(ctx, c, ns, name)
+			if err != nil {
+				return err
+			}
+			if cl.Health() == cratedb.HealthGreen {
+				return nil
+			}
+			cli.Debugf("%s/%s: shards recovered but health is %s", ns, name, cl.Health())
+		} else {
+			cli.Debugf("%s/%s: %d shard(s) recovering", ns, name, int(busy))
+		}
+		if err := cli.Sleep(ctx, kube.WaitBackoff); err != nil {
+			return err
+		}
+	}
+}
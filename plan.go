@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "plan",
+		summary: "print the pod-by-pod restart order, or emit a RestartPlan CR for GitOps",
+		run:     runPlan,
+	})
+}
+
+// planAPIVersion is the group/version of the emitted RestartPlan CR.
+// The in-cluster controller consuming it has not shipped yet; until it
+// does, the manifest is a reviewable, merge-able record of a proposed
+// campaign rather than something that executes on apply.
+const planAPIVersion = "crt.walbeh.dev/v1alpha1"
+
+// runPlan resolves targets to the exact pod order a campaign would
+// use. By default it prints that order as a table; with -as-cr it
+// emits a RestartPlan CR manifest on stdout so campaigns can be
+// proposed via pull request and executed after merge.
+func runPlan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "podTimeout recorded in the plan")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "healthTimeout recorded in the plan")
+	fs.IntVar(&opts.Parallel, "parallel", opts.Parallel, "parallel recorded in the plan")
+	fs.StringVar(&opts.MinHealth, "min-health", opts.MinHealth, "minHealth recorded in the plan")
+	asCR := fs.Bool("as-cr", false, "emit a RestartPlan CR manifest instead of a table")
+	impact := fs.Bool("impact", false,
+		"add per-step recovery traffic and capacity columns from sys.shards (table output; connects via SQL)")
+	name := fs.String("name", "", "metadata.name of the emitted CR (default restart-<date>)")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if !cratedb.KnownHealth(opts.MinHealth) {
+		return fmt.Errorf("invalid -min-health %q", opts.MinHealth)
+	}
+	targets, err := parseTargets(fs.Args(), &g)
+	if err != nil {
+		return err
+	}
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+
+	type clusterPlan struct {
+		target     campaign.Target
+		pods       []string
+		nodeBytes  map[string]float64 // shard bytes hosted per pod, from sys.shards
+		totalBytes float64
+	}
+	plans := make([]clusterPlan, 0, len(targets))
+	for _, t := range targets {
+		cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+		if err != nil {
+			return err
+		}
+		pods, err := cratedb.Pods(ctx, c, cl)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("%s: no crate pods found", t)
+		}
+		p := clusterPlan{target: t}
+		for _, pod := range cratedb.RestartOrder(pods, t.Name, nil) {
+			p.pods = append(p.pods, unstructured.NestedString(pod, "metadata", "name"))
+		}
+		if *impact {
+			if p.nodeBytes, p.totalBytes, err = shardBytesPerPod(ctx, c, t); err != nil {
+				return err
+			}
+		}
+		plans = append(plans, p)
+	}
+
+	if *asCR {
+		crName := *name
+		if crName == "" {
+			crName = "restart-" + time.Now().UTC().Format("20060102-150405")
+		}
+		var b strings.Builder
+		b.WriteString("apiVersion: " + planAPIVersion + "\nkind: RestartPlan\nmetadata:\n")
+		b.WriteString("  name: " + crName + "\n")
+		b.WriteString("  namespace: " + c.Namespace() + "\n")
+		b.WriteString("spec:\n")
+		b.WriteString("  minHealth: " + opts.MinHealth + "\n")
+		b.WriteString(fmt.Sprintf("  podTimeout: %s\n", opts.PodTimeout))
+		b.WriteString(fmt.Sprintf("  healthTimeout: %s\n", opts.HealthTimeout))
+		b.WriteString(fmt.Sprintf("  parallel: %d\n", opts.Parallel))
+		b.WriteString("  clusters:\n")
+		for _, p := range plans {
+			b.WriteString("  - namespace: " + p.target.Namespace + "\n")
+			b.WriteString("    name: " + p.target.Name + "\n")
+			b.WriteString("    pods:\n")
+			for _, pod := range p.pods {
+				b.WriteString("    - " + pod + "\n")
+			}
+		}
+		fmt.Print(b.String())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	header := "CLUSTER\tORDER\tPOD"
+	if *impact {
+		header += "\tRECOVERY\tCAPACITY"
+	}
+	fmt.Fprintln(w, header)
+	for _, p := range plans {
+		for i, pod := range p.pods {
+			line := fmt.Sprintf("%s\t%d/%d\t%s", p.target, i+1, len(p.pods), pod)
+			if *impact {
+				// Taking a pod down re-replicates or recovers the shard
+				// bytes it hosts; that is both the extra network traffic
+				// of the step and the share of replica capacity missing
+				// while it is out.
+				bytes := p.nodeBytes[pod]
+				capacity := "-"
+				if p.totalBytes > 0 {
+					capacity = fmt.Sprintf("-%.0f%%", bytes/p.totalBytes*100)
+				}
+				line += fmt.Sprintf("\t%s\t%s", fmtBytes(int64(bytes)), capacity)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	return w.Flush()
+}
+
+// shardBytesPerPod returns the shard bytes hosted by each pod of the
+// cluster and the cluster-wide total, the basis for the -impact
+// columns.
+func shardBytesPerPod(ctx context.Context, c *kube.Client, t campaign.Target) (map[string]float64, float64, error) {
+	sql, err := cratesql.Connect(ctx, c, t.Namespace, t.Name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: opening SQL connection: %w", t, err)
+	}
+	defer sql.Close()
+	res, err := sql.Exec(ctx, "SELECT _node['hostname'], coalesce(sum(size), 0) FROM sys.shards GROUP BY 1")
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: reading sys.shards: %w", t, err)
+	}
+	bytes := map[string]float64{}
+	var total float64
+	for _, row := range res.Rows {
+		pod, _ := row[0].(string)
+		size, _ := row[1].(float64)
+		bytes[pod] = size
+		total += size
+	}
+	return bytes, total, nil
+}
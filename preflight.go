@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/certs"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/operator"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "preflight",
+		summary: "check clusters are ready for a restart campaign, with CI-friendly output",
+		run:     runPreflight,
+	})
+}
+
+// preflightResult is one check outcome for one cluster. Check is a
+// stable rule id (backups, rbac, cert-expiry) so CI systems can track
+// the same finding across runs.
+type preflightResult struct {
+	Target campaign.Target
+	Check  string
+	Passed bool
+	Detail string
+}
+
+// preflightRules describes each check for the SARIF rule catalog.
+var preflightRules = []struct{ id, desc string }{
+	{"backups", "the CrateDB spec schedules backups"},
+	{"rbac", "the current identity holds the permissions a restart needs"},
+	{"cert-expiry", "no TLS certificate expires within the warning window"},
+	{"operator", "the crate-operator is ready and its webhooks are reachable"},
+	{"scheduling", "ready nodes match the StatefulSet's nodeSelector and tolerations"},
+}
+
+func runPreflight(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	format := fs.String("format", "text", "output format: text, junit or sarif")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	certWarn := fs.Duration("cert-warn", 30*24*time.Hour,
+		"fail the cert-expiry check when a certificate expires within this window")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	targets, err := parseTargets(fs.Args(), &g)
+	if err != nil {
+		return err
+	}
+
+	// The operator serves the whole fleet, so check it once and report
+	// the outcome under every cluster's suite.
+	operatorCheck := preflightResult{Check: "operator", Passed: true, Detail: "ready"}
+	if err := operator.CheckAvailable(ctx, c); err != nil {
+		operatorCheck = preflightResult{Check: "operator", Detail: err.Error()}
+	}
+
+	var results []preflightResult
+	rbacByNS := map[string]preflightResult{}
+	for _, t := range targets {
+		cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+		if err != nil {
+			return err
+		}
+		results = append(results, checkBackups(t, cl))
+		// RBAC is granted per namespace, so one review round covers
+		// every cluster in it.
+		rbac, ok := rbacByNS[t.Namespace]
+		if !ok {
+			rbac, err = checkRestartRBAC(ctx, c, t.Namespace)
+			if err != nil {
+				return err
+			}
+			rbacByNS[t.Namespace] = rbac
+		}
+		rbac.Target = t
+		results = append(results, rbac)
+		results = append(results, checkCertExpiry(ctx, c, t, cl, *certWarn))
+		operatorCheck.Target = t
+		results = append(results, operatorCheck)
+		results = append(results, checkScheduling(ctx, c, t, cl))
+	}
+
+	var rendered []byte
+	switch *format {
+	case "text":
+		rendered = []byte(renderPreflightText(results))
+	case "junit":
+		rendered = []byte(renderJUnit(results))
+	case "sarif":
+		rendered, err = renderSARIF(results)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown -format %q (want text, junit or sarif)", *format)
+	}
+	if *out == "" {
+		os.Stdout.Write(rendered)
+	} else {
+		if err := os.WriteFile(*out, rendered, 0o644); err != nil {
+			return err
+		}
+		cli.Infof("report written to %s", *out)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d preflight checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// checkBackups verifies the CR schedules backups. The backup section
+// is operator-version specific, so the check only asks whether
+// spec.backups exists and is non-empty.
+func checkBackups(t campaign.Target, cl cratedb.Cluster) preflightResult {
+	backups := unstructured.NestedMap(cl.Obj, "spec", "backups")
+	if len(backups) == 0 {
+		return preflightResult{Target: t, Check: "backups",
+			Detail: "spec.backups is not configured; nothing snapshots this cluster"}
+	}
+	kinds := make([]string, 0, len(backups))
+	for k := range backups {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return preflightResult{Target: t, Check: "backups", Passed: true,
+		Detail: "configured: " + strings.Join(kinds, ", ")}
+}
+
+// checkRestartRBAC reviews the restart-tier permissions (the rbac
+// generate -mode=restart set) against the apiserver's authorizer.
+func checkRestartRBAC(ctx context.Context, c *kube.Client, namespace string) (preflightResult, error) {
+	rules, err := rbacRules("restart")
+	if err != nil {
+		return preflightResult{}, err
+	}
+	seen := map[string]bool{}
+	var missing []string
+	for _, rule := range rules {
+		for _, group := range rule.apiGroups {
+			for _, resource := range rule.resources {
+				for _, verb := range rule.verbs {
+					id := group + "/" + resource + ":" + verb
+					if seen[id] {
+						continue
+					}
+					seen[id] = true
+					allowed, err := c.CanI(ctx, namespace, group, resource, verb)
+					if err != nil {
+						return preflightResult{}, fmt.Errorf("access review in %s: %w", namespace, err)
+					}
+					if !allowed {
+						missing = append(missing, strings.TrimPrefix(id, "/"))
+					}
+				}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return preflightResult{Check: "rbac",
+			Detail: "missing permissions: " + strings.Join(missing, ", ")}, nil
+	}
+	return preflightResult{Check: "rbac", Passed: true,
+		Detail: fmt.Sprintf("all %d restart-tier permissions granted in %s", len(seen), namespace)}, nil
+}
+
+// checkCertExpiry fails when the soonest-expiring TLS certificate of
+// the cluster falls within the warning window.
+func checkCertExpiry(ctx context.Context, c *kube.Client, t campaign.Target, cl cratedb.Cluster, warn time.Duration) preflightResult {
+	infos, err := certs.ForCluster(ctx, c, cl)
+	if err != nil {
+		return preflightResult{Target: t, Check: "cert-expiry", Detail: err.Error()}
+	}
+	soonest := certs.SoonestExpiry(infos)
+	if soonest.IsZero() {
+		return preflightResult{Target: t, Check: "cert-expiry", Passed: true,
+			Detail: "no TLS certificates found"}
+	}
+	detail := fmt.Sprintf("soonest expiry %s (%dd)", soonest.Format("2006-01-02"), int(time.Until(soonest).Hours()/24))
+	if time.Until(soonest) < warn {
+		for _, in := range infos {
+			if in.NotAfter.Equal(soonest) {
+				detail = fmt.Sprintf("secret %s expires %s, within the %s warning window",
+					in.Secret, soonest.Format("2006-01-02"), warn)
+				break
+			}
+		}
+		return preflightResult{Target: t, Check: "cert-expiry", Detail: detail}
+	}
+	return preflightResult{Target: t, Check: "cert-expiry", Passed: true, Detail: detail}
+}
+
+// checkScheduling verifies the cluster's StatefulSets can still be
+// scheduled: at least one ready node matches their nodeSelector and
+// tolerates their taints. A campaign run while a node pool is being
+// replaced fails here instead of stranding the first replacement pod.
+func checkScheduling(ctx context.Context, c *kube.Client, t campaign.Target, cl cratedb.Cluster) preflightResult {
+	sets, err := cratedb.StatefulSets(ctx, c, []cratedb.Cluster{cl})
+	if err != nil {
+		return preflightResult{Target: t, Check: "scheduling", Detail: err.Error()}
+	}
+	stss := sets[t.Namespace+"/"+t.Name]
+	if len(stss) == 0 {
+		return preflightResult{Target: t, Check: "scheduling",
+			Detail: "no crate StatefulSets found for the cluster"}
+	}
+	nodes, err := c.List(ctx, kube.ResourcePath("v1", "", "nodes", ""), nil)
+	if err != nil {
+		return preflightResult{Target: t, Check: "scheduling", Detail: err.Error()}
+	}
+	var details []string
+	for _, sts := range stss {
+		name := unstructured.NestedString(sts, "metadata", "name")
+		selector := unstructured.NestedMap(sts, "spec", "template", "spec", "nodeSelector")
+		tolerations := unstructured.NestedSlice(sts, "spec", "template", "spec", "tolerations")
+		matching := 0
+		for _, n := range nodes {
+			if nodeAdmits(n, selector, tolerations) {
+				matching++
+			}
+		}
+		if matching == 0 {
+			return preflightResult{Target: t, Check: "scheduling",
+				Detail: fmt.Sprintf("statefulset %s: no ready node matches its nodeSelector and tolerations; is the node pool being replaced?", name)}
+		}
+		details = append(details, fmt.Sprintf("%s: %d node(s)", name, matching))
+	}
+	return preflightResult{Target: t, Check: "scheduling", Passed: true,
+		Detail: strings.Join(details, ", ")}
+}
+
+// nodeAdmits reports whether a ready, schedulable node carries the
+// selector labels and only taints the tolerations cover.
+func nodeAdmits(node map[string]any, selector map[string]any, tolerations []any) bool {
+	if unstructured.NestedBool(node, "spec", "unschedulable") {
+		return false
+	}
+	ready := false
+	for _, cond := range unstructured.NestedSlice(node, "status", "conditions") {
+		if m, ok := cond.(map[string]any); ok && unstructured.NestedString(m, "type") == "Ready" {
+			ready = unstructured.NestedString(m, "status") == "True"
+		}
+	}
+	if !ready {
+		return false
+	}
+	labels := unstructured.NestedMap(node, "metadata", "labels")
+	for k, v := range selector {
+		if fmt.Sprint(labels[k]) != fmt.Sprint(v) {
+			return false
+		}
+	}
+	for _, taint := range unstructured.NestedSlice(node, "spec", "taints") {
+		tm, ok := taint.(map[string]any)
+		if !ok {
+			continue
+		}
+		effect := unstructured.NestedString(tm, "effect")
+		if effect != "NoSchedule" && effect != "NoExecute" {
+			continue
+		}
+		if !tolerated(tm, tolerations) {
+			return false
+		}
+	}
+	return true
+}
+
+// tolerated implements the Kubernetes toleration match: effect (empty
+// tolerates all), then key/value under the Exists or Equal operator.
+func tolerated(taint map[string]any, tolerations []any) bool {
+	for _, tol := range tolerations {
+		m, ok := tol.(map[string]any)
+		if !ok {
+			continue
+		}
+		if e := unstructured.NestedString(m, "effect"); e != "" && e != unstructured.NestedString(taint, "effect") {
+			continue
+		}
+		key := unstructured.NestedString(m, "key")
+		switch unstructured.NestedString(m, "operator") {
+		case "Exists":
+			if key == "" || key == unstructured.NestedString(taint, "key") {
+				return true
+			}
+		default: // Equal
+			if key == unstructured.NestedString(taint, "key") &&
+				unstructured.NestedString(m, "value") == unstructured.NestedString(taint, "value") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func renderPreflightText(results []preflightResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%s %s %s: %s\n", status, r.Target, r.Check, r.Detail)
+	}
+	return b.String()
+}
+
+// renderJUnit emits one testsuite per cluster with a testcase per
+// check, the shape CI systems ingest as native test results.
+func renderJUnit(results []preflightResult) string {
+	byTarget := map[string][]preflightResult{}
+	var order []string
+	for _, r := range results {
+		key := r.Target.String()
+		if _, ok := byTarget[key]; !ok {
+			order = append(order, key)
+		}
+		byTarget[key] = append(byTarget[key], r)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<testsuites>\n")
+	for _, key := range order {
+		suite := byTarget[key]
+		failures := 0
+		for _, r := range suite {
+			if !r.Passed {
+				failures++
+			}
+		}
+		fmt.Fprintf(&b, `  <testsuite name="%s" tests="%d" failures="%d">`+"\n",
+			xmlEscape(key), len(suite), failures)
+		for _, r := range suite {
+			if r.Passed {
+				fmt.Fprintf(&b, `    <testcase classname="%s" name="%s"/>`+"\n",
+					xmlEscape(key), xmlEscape(r.Check))
+				continue
+			}
+			fmt.Fprintf(&b, `    <testcase classname="%s" name="%s"><failure message="%s"/></testcase>`+"\n",
+				xmlEscape(key), xmlEscape(r.Check), xmlEscape(r.Detail))
+		}
+		b.WriteString("  </testsuite>\n")
+	}
+	b.WriteString("</testsuites>\n")
+	return b.String()
+}
+
+// renderSARIF emits a SARIF 2.1.0 log: the check catalog as rules and
+// one error-level result per failed check, located by cluster name.
+func renderSARIF(results []preflightResult) ([]byte, error) {
+	rules := make([]map[string]any, 0, len(preflightRules))
+	for _, r := range preflightRules {
+		rules = append(rules, map[string]any{
+			"id":               r.id,
+			"shortDescription": map[string]any{"text": r.desc},
+		})
+	}
+	findings := []map[string]any{}
+	for _, r := range results {
+		if r.Passed {
+			continue
+		}
+		findings = append(findings, map[string]any{
+			"ruleId":  r.Check,
+			"level":   "error",
+			"message": map[string]any{"text": r.Target.String() + ": " + r.Detail},
+			"locations": []map[string]any{{
+				"logicalLocations": []map[string]any{{"fullyQualifiedName": r.Target.String()}},
+			}},
+		})
+	}
+	doc := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool":    map[string]any{"driver": map[string]any{"name": progName, "rules": rules}},
+			"results": findings,
+		}},
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;",
+	).Replace(s)
+}
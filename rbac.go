@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+func init() {
+	register(command{
+		name:    "rbac",
+		summary: "generate the RBAC manifests the tool needs: generate",
+		run:     runRBAC,
+	})
+}
+
+// rbacRule is one PolicyRule, rendered to YAML by hand — the manifests
+// are simple enough that a template beats a YAML dependency.
+type rbacRule struct {
+	apiGroups []string
+	resources []string
+	verbs     []string
+}
+
+// rbacRules returns the least-privilege rules for a mode. Modes are
+// cumulative: restart includes read-only, controller includes restart.
+func rbacRules(mode string) ([]rbacRule, error) {
+	readOnly := []rbacRule{
+		{[]string{"cloud.crate.io"}, []string{"cratedbs"}, []string{"get", "list", "watch"}},
+		{[]string{""}, []string{"pods", "persistentvolumeclaims", "events", "configmaps"}, []string{"get", "list"}},
+		{[]string{""}, []string{"secrets"}, []string{"get", "list"}},
+		{[]string{""}, []string{"pods/log"}, []string{"get"}},
+		{[]string{"apps"}, []string{"statefulsets"}, []string{"get", "list"}},
+	}
+	restart := append(readOnly,
+		rbacRule{[]string{""}, []string{"pods"}, []string{"delete"}},
+		rbacRule{[]string{""}, []string{"events"}, []string{"create"}},
+		rbacRule{[]string{"cloud.crate.io"}, []string{"cratedbs"}, []string{"patch"}},
+		rbacRule{[]string{""}, []string{"pods/portforward"}, []string{"create"}},
+		rbacRule{[]string{""}, []string{"services"}, []string{"get"}},
+	)
+	controller := append(restart,
+		rbacRule{[]string{"cloud.crate.io"}, []string{"cratedbs"}, []string{"patch"}},
+		rbacRule{[]string{""}, []string{"secrets", "persistentvolumeclaims"}, []string{"patch", "create"}},
+		rbacRule{[]string{""}, []string{"configmaps"}, []string{"watch"}},
+		rbacRule{[]string{""}, []string{"secrets"}, []string{"watch"}},
+		rbacRule{[]string{"coordination.k8s.io"}, []string{"leases"}, []string{"get", "create", "patch"}},
+	)
+	switch mode {
+	case "read-only":
+		return readOnly, nil
+	case "restart":
+		return restart, nil
+	case "controller":
+		return controller, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q (want read-only, restart or controller)", mode)
+	}
+}
+
+func runRBAC(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rbac", flag.ExitOnError)
+	mode := fs.String("mode", "read-only", "privilege tier: read-only, restart or controller")
+	namespace := fs.String("namespace", "", "bind in this namespace (empty: cluster-wide)")
+	serviceAccount := fs.String("service-account", "crt", "subject ServiceAccount name")
+
+	if len(args) < 1 || args[0] != "generate" {
+		return fmt.Errorf("usage: crt rbac generate [-mode=...] [-namespace=...] [-service-account=...]")
+	}
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+	rules, err := rbacRules(*mode)
+	if err != nil {
+		return err
+	}
+	fmt.Print(renderRBAC(*mode, *namespace, *serviceAccount, rules))
+	return nil
+}
+
+func renderRBAC(mode, namespace, serviceAccount string, rules []rbacRule) string {
+	var b strings.Builder
+	name := "crt-" + mode
+
+	roleKind, bindingKind := "ClusterRole", "ClusterRoleBinding"
+	meta := "  name: " + name + "\n"
+	if namespace != "" {
+		roleKind, bindingKind = "Role", "RoleBinding"
+		meta += "  namespace: " + namespace + "\n"
+	}
+
+	saNamespace := namespace
+	if saNamespace == "" {
+		saNamespace = "default"
+	}
+	b.WriteString("apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: " + serviceAccount + "\n  namespace: " + saNamespace + "\n")
+
+	b.WriteString("---\napiVersion: rbac.authorization.k8s.io/v1\nkind: " + roleKind + "\nmetadata:\n" + meta + "rules:\n")
+	for _, r := range rules {
+		b.WriteString("- apiGroups: [" + quoteJoin(r.apiGroups) + "]\n")
+		b.WriteString("  resources: [" + quoteJoin(r.resources) + "]\n")
+		b.WriteString("  verbs: [" + quoteJoin(r.verbs) + "]\n")
+	}
+
+	b.WriteString("---\napiVersion: rbac.authorization.k8s.io/v1\nkind: " + bindingKind + "\nmetadata:\n" + meta)
+	b.WriteString("roleRef:\n  apiGroup: rbac.authorization.k8s.io\n  kind: " + roleKind + "\n  name: " + name + "\n")
+	b.WriteString("subjects:\n- kind: ServiceAccount\n  name: " + serviceAccount + "\n  namespace: " + saNamespace + "\n")
+	return b.String()
+}
+
+func quoteJoin(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = `"` + s + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
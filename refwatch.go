@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/certs"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// refWatcher detects content changes in the Secrets and ConfigMaps a
+// cluster's StatefulSet references and schedules a gated rolling
+// restart when they change. Clusters opt in with the
+// crt.walbeh.dev/restart-on-change=true annotation.
+type refWatcher struct {
+	mu         sync.Mutex
+	hashes     map[string]map[string]string // cluster key -> object -> hash
+	restarting map[string]bool
+}
+
+func newRefWatcher() *refWatcher {
+	return &refWatcher{
+		hashes:     map[string]map[string]string{},
+		restarting: map[string]bool{},
+	}
+}
+
+// check compares current reference hashes for all opted-in clusters
+// and kicks off restarts for changed ones. Called from the serve poll
+// loop.
+func (rw *refWatcher) check(ctx context.Context, c *kube.Client, clusters []cratedb.Cluster) {
+	var watched []cratedb.Cluster
+	for _, cl := range clusters {
+		if cl.Annotation("restart-on-change") == "true" {
+			watched = append(watched, cl)
+		}
+	}
+	if len(watched) == 0 {
+		return
+	}
+	stsIndex, err := cratedb.StatefulSets(ctx, c, watched)
+	if err != nil {
+		cli.Warnf("statefulset discovery: %v", err)
+	}
+	for _, cl := range watched {
+		key := cl.Namespace + "/" + cl.Name
+		current, err := rw.referenceHashes(ctx, c, cl, stsIndex[key])
+		if err != nil {
+			cli.Warnf("reference hashes for %s: %v", key, err)
+			continue
+		}
+		rw.mu.Lock()
+		prev, seen := rw.hashes[key]
+		rw.hashes[key] = current
+		busy := rw.restarting[key]
+		rw.mu.Unlock()
+		if !seen || busy {
+			continue // first observation only primes the baseline
+		}
+		if changed := changedRefs(prev, current); len(changed) > 0 {
+			cli.Infof("%s: referenced objects changed (%v), scheduling rolling restart", key, changed)
+			rw.mu.Lock()
+			rw.restarting[key] = true
+			rw.mu.Unlock()
+			go func(t campaign.Target, key string) {
+				cp := campaign.New([]campaign.Target{t}, campaign.DefaultOptions())
+				if err := cp.Run(ctx, c); err != nil {
+					cli.Warnf("auto-restart of %s: %v", key, err)
+				}
+				rw.mu.Lock()
+				rw.restarting[key] = false
+				rw.mu.Unlock()
+			}(campaign.Target{Namespace: cl.Namespace, Name: cl.Name}, key)
+		}
+	}
+}
+
+// referenceHashes hashes every Secret and ConfigMap named in the
+// cluster's StatefulSet pod templates. The StatefulSets come from the
+// shared discovery index so callers pay one list per namespace, not
+// one per cluster.
+func (rw *refWatcher) referenceHashes(ctx context.Context, c *kube.Client, cl cratedb.Cluster, stss []map[string]any) (map[string]string, error) {
+	hashes := map[string]string{}
+	for _, sts := range stss {
+		secrets, configmaps := referencedObjects(unstructured.NestedMap(sts, "spec", "template", "spec"))
+		for _, name := range secrets {
+			var obj map[string]any
+			if err := c.Get(ctx, kube.ResourcePath("v1", cl.Namespace, "secrets", name), &obj); err != nil {
+				continue // references may point at optional objects
+			}
+			hashes["secret/"+name] = certs.HashSecretData(obj)
+		}
+		for _, name := range configmaps {
+			var obj map[string]any
+			if err := c.Get(ctx, kube.ResourcePath("v1", cl.Namespace, "configmaps", name), &obj); err != nil {
+				continue
+			}
+			hashes["configmap/"+name] = certs.HashSecretData(obj)
+		}
+	}
+	return hashes, nil
+}
+
+// referencedObjects extracts secret and configmap names from a pod
+// spec: volumes, env valueFrom, and envFrom.
+func referencedObjects(podSpec map[string]any) (secrets, configmaps []string) {
+	add := func(list *[]string, name string) {
+		if name == "" {
+			return
+		}
+		for _, n := range *list {
+			if n == name {
+				return
+			}
+		}
+		*list = append(*list, name)
+	}
+	for _, v := range unstructured.NestedSlice(podSpec, "volumes") {
+		vol, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		add(&secrets, unstructured.NestedString(vol, "secret", "secretName"))
+		add(&configmaps, unstructured.NestedString(vol, "configMap", "name"))
+	}
+	for _, cv := range unstructured.NestedSlice(podSpec, "containers") {
+		container, ok := cv.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, ev := range unstructured.NestedSlice(container, "env") {
+			env, ok := ev.(map[string]any)
+			if !ok {
+				continue
+			}
+			add(&secrets, unstructured.NestedString(env, "valueFrom", "secretKeyRef", "name"))
+			add(&configmaps, unstructured.NestedString(env, "valueFrom", "configMapKeyRef", "name"))
+		}
+		for _, ef := range unstructured.NestedSlice(container, "envFrom") {
+			from, ok := ef.(map[string]any)
+			if !ok {
+				continue
+			}
+			add(&secrets, unstructured.NestedString(from, "secretRef", "name"))
+			add(&configmaps, unstructured.NestedString(from, "configMapRef", "name"))
+		}
+	}
+	return secrets, configmaps
+}
+
+func changedRefs(prev, current map[string]string) []string {
+	var changed []string
+	for name, hash := range current {
+		if old, ok := prev[name]; ok && old != hash {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "replay",
+		summary: "replay a -record file and explain each pod deletion from the recorded state",
+		run:     runReplay,
+	})
+}
+
+// runReplay walks a recording written with -record and, for every pod
+// deletion, reconstructs the inputs the tool had observed at that
+// moment: the cluster health it last read, pods that were not Ready,
+// and whether the deletion respected the highest-ordinal-first order
+// within its pool. It answers "why did it restart that pod?" from the
+// record alone, long after the cluster has moved on.
+func runReplay(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt replay <record.jsonl>")
+	}
+	records, err := kube.ReadRecords(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	lastHealth := map[string]string{} // ns/cluster -> health last read
+	lastPods := map[string][]string{} // ns -> pods not Ready at last list
+	lastOrdinal := map[string]int{}   // ns/pool -> ordinal of previous delete
+	deletes := 0
+	for _, rec := range records {
+		ns, plural, name := kube.SplitResourcePath(rec.Path)
+		switch {
+		case rec.Method == "GET" && plural == cratedb.Plural && rec.Response != nil:
+			for _, obj := range recordedObjects(rec.Response) {
+				key := unstructured.NestedString(obj, "metadata", "namespace") + "/" +
+					unstructured.NestedString(obj, "metadata", "name")
+				lastHealth[key] = unstructured.NestedString(obj, "status", "crateDBStatus", "health")
+			}
+		case rec.Method == "GET" && plural == "pods" && name == "" && rec.Response != nil:
+			var notReady []string
+			for _, obj := range recordedObjects(rec.Response) {
+				if !cratedb.PodReady(obj) {
+					notReady = append(notReady, unstructured.NestedString(obj, "metadata", "name"))
+				}
+			}
+			lastPods[ns] = notReady
+		case rec.Method == "DELETE" && plural == "pods":
+			deletes++
+			pool, ordinal := podPoolOrdinal(name)
+			fmt.Printf("%s  deleted %s/%s\n", rec.Time.Format("15:04:05"), ns, name)
+			for key, h := range lastHealth {
+				if strings.HasPrefix(key, ns+"/") {
+					fmt.Printf("          last observed health of %s: %s\n", key, orDash(h, "-"))
+				}
+			}
+			if len(lastPods[ns]) > 0 {
+				fmt.Printf("          pods not Ready at last list: %s\n", strings.Join(lastPods[ns], ", "))
+			}
+			poolKey := ns + "/" + pool
+			if prev, seen := lastOrdinal[poolKey]; seen && ordinal > prev {
+				fmt.Printf("          ORDER VIOLATION: ordinal %d deleted after %d in pool %s\n", ordinal, prev, pool)
+			}
+			lastOrdinal[poolKey] = ordinal
+		}
+	}
+	fmt.Printf("\n%d record(s), %d pod deletion(s)\n", len(records), deletes)
+	return nil
+}
+
+// recordedObjects extracts objects from a recorded GET response, which
+// is either a single object or a list with items.
+func recordedObjects(raw json.RawMessage) []map[string]any {
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil
+	}
+	if items, ok := obj["items"].([]any); ok {
+		out := make([]map[string]any, 0, len(items))
+		for _, it := range items {
+			if m, ok := it.(map[string]any); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	return []map[string]any{obj}
+}
+
+// podPoolOrdinal splits a crate pod name into its pool prefix and
+// StatefulSet ordinal.
+func podPoolOrdinal(name string) (string, int) {
+	idx := strings.LastIndex(name, "-")
+	if idx < 0 {
+		return name, -1
+	}
+	ordinal, err := strconv.Atoi(name[idx+1:])
+	if err != nil {
+		return name, -1
+	}
+	return name[:idx], ordinal
+}
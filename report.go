@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/certs"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/xlsx"
+)
+
+func init() {
+	register(command{
+		name:    "report",
+		summary: "export fleet state for ops reviews: report fleet -out fleet.xlsx",
+		run:     runReport,
+	})
+}
+
+// fleetRow is one cluster in the fleet report.
+type fleetRow struct {
+	Context, Namespace, Name, Version string
+	Nodes                             int64
+	Health, Sizes                     string
+	LastRestart, CertExpiry           string
+}
+
+func runReport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	out := fs.String("out", "fleet.xlsx", "output file; the .xlsx or .csv extension decides the format")
+	if len(args) < 1 || args[0] != "fleet" {
+		return fmt.Errorf("usage: %s report fleet [-out fleet.xlsx]", progName)
+	}
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	rows, err := sweepFleetReport(ctx, &g)
+	if err != nil {
+		return err
+	}
+	switch filepath.Ext(*out) {
+	case ".csv":
+		err = writeFleetCSV(*out, rows)
+	case ".xlsx":
+		err = writeFleetXLSX(*out, rows)
+	default:
+		return fmt.Errorf("unsupported extension %q (want .xlsx or .csv)", filepath.Ext(*out))
+	}
+	if err != nil {
+		return err
+	}
+	cli.Infof("wrote %d cluster(s) to %s", len(rows), *out)
+	return nil
+}
+
+// sweepFleetReport collects the report rows across every kubeconfig
+// context, skipping contexts that cannot be reached like the
+// inventory sweep does.
+func sweepFleetReport(ctx context.Context, g *cli.GlobalFlags) ([]fleetRow, error) {
+	contexts, err := kube.Contexts(g.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("kubeconfig has no contexts")
+	}
+	var rows []fleetRow
+	for _, kctx := range contexts {
+		cfg, err := kube.LoadConfig(kctx, g.Kubeconfig)
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		c, err := kube.NewClient(cfg)
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		clusters, err := cratedb.List(ctx, c, "")
+		if err != nil {
+			cli.Warnf("context %s: %v", kctx, err)
+			continue
+		}
+		for _, cl := range clusters {
+			row := fleetRow{
+				Context:     kctx,
+				Namespace:   cl.Namespace,
+				Name:        cl.Name,
+				Version:     cl.Version(),
+				Nodes:       cl.DataNodes(),
+				Health:      cl.Health(),
+				Sizes:       poolSizes(cl),
+				LastRestart: lastRestart(cl),
+				CertExpiry:  "-",
+			}
+			if infos, err := certs.ForCluster(ctx, c, cl); err != nil {
+				cli.Debugf("%s/%s: %v", cl.Namespace, cl.Name, err)
+			} else if soonest := certs.SoonestExpiry(infos); !soonest.IsZero() {
+				row.CertExpiry = soonest.Format("2006-01-02")
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// poolSizes joins the per-pool disk sizes, e.g. "hot=1Ti,cold=4Ti".
+func poolSizes(cl cratedb.Cluster) string {
+	var parts []string
+	for _, pool := range cl.Spec.Nodes.Data {
+		if pool.Resources.Disk.Size != "" {
+			parts = append(parts, pool.Name+"="+pool.Resources.Disk.Size)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ",")
+}
+
+// lastRestart derives the date of the last campaign from the
+// last-campaign annotation, whose ID starts with a timestamp.
+func lastRestart(cl cratedb.Cluster) string {
+	id := cl.Annotation("last-campaign")
+	if len(id) >= 15 {
+		if t, err := time.Parse("20060102-150405", id[:15]); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return "-"
+}
+
+var fleetHeader = []string{"NAMESPACE", "NAME", "VERSION", "NODES", "HEALTH", "SIZES", "LAST-RESTART", "CERT-EXPIRY"}
+
+func (r fleetRow) cells() []string {
+	return []string{r.Namespace, r.Name, r.Version, fmt.Sprint(r.Nodes), r.Health, r.Sizes, r.LastRestart, r.CertExpiry}
+}
+
+func writeFleetCSV(path string, rows []fleetRow) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(append([]string{"CONTEXT"}, fleetHeader...))
+	for _, r := range rows {
+		w.Write(append([]string{r.Context}, r.cells()...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFleetXLSX renders one sheet per kubeconfig context, the layout
+// the monthly ops review reads.
+func writeFleetXLSX(path string, rows []fleetRow) error {
+	wb := xlsx.New()
+	sheets := map[string]*xlsx.Sheet{}
+	for _, r := range rows {
+		sheet, ok := sheets[r.Context]
+		if !ok {
+			sheet = wb.AddSheet(r.Context)
+			sheet.AddRow(fleetHeader...)
+			sheets[r.Context] = sheet
+		}
+		sheet.AddRow(r.cells()...)
+	}
+	if len(sheets) == 0 {
+		wb.AddSheet("fleet").AddRow(fleetHeader...)
+	}
+	return wb.WriteFile(path)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "resize-storage",
+		summary: "expand a cluster's PVCs and restart pods for filesystem growth",
+		run:     runResizeStorage,
+	})
+}
+
+func runResizeStorage(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("resize-storage", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	size := fs.String("size", "", "new volume size, e.g. 500Gi (required)")
+	skipRestart := fs.Bool("skip-restart", false, "patch sizes but skip the rolling restart")
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "max wait for a pod to return Ready")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "max wait for the cluster to report GREEN")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *size == "" {
+		return fmt.Errorf("usage: crt resize-storage [namespace/]name -size=500Gi")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{"labelSelector": []string{cl.PodSelector()}}
+	pvcs, err := c.List(ctx, kube.ResourcePath("v1", ns, "persistentvolumeclaims", ""), q)
+	if err != nil {
+		return fmt.Errorf("listing PVCs: %w", err)
+	}
+	if len(pvcs) == 0 {
+		return fmt.Errorf("no PVCs found for %s/%s", ns, name)
+	}
+
+	// Refuse up front when the StorageClass cannot expand volumes.
+	checked := map[string]bool{}
+	for _, pvc := range pvcs {
+		sc := unstructured.NestedString(pvc, "spec", "storageClassName")
+		if sc == "" || checked[sc] {
+			continue
+		}
+		checked[sc] = true
+		var scObj map[string]any
+		if err := c.Get(ctx, kube.ResourcePath("storage.k8s.io/v1", "", "storageclasses", sc), &scObj); err != nil {
+			return fmt.Errorf("reading StorageClass %s: %w", sc, err)
+		}
+		if !unstructured.NestedBool(scObj, "allowVolumeExpansion") {
+			return fmt.Errorf("StorageClass %s does not allow volume expansion", sc)
+		}
+	}
+
+	// Keep the CR's disk size in sync so the operator does not fight
+	// the PVC patches. The data pool array is replaced wholesale since
+	// merge patches cannot address array elements.
+	pools := unstructured.NestedSlice(cl.Obj, "spec", "nodes", "data")
+	for _, p := range pools {
+		if pool, ok := p.(map[string]any); ok {
+			if disk := unstructured.NestedMap(pool, "resources", "disk"); disk != nil {
+				disk["size"] = *size
+			}
+		}
+	}
+	crPatch := map[string]any{
+		"apiVersion": cratedb.APIVersion,
+		"kind":       "CrateDB",
+		"metadata":   map[string]any{"name": name, "namespace": ns},
+		"spec":       map[string]any{"nodes": map[string]any{"data": pools}},
+	}
+	if err := c.Apply(ctx, kube.ResourcePath(cratedb.APIVersion, ns, cratedb.Plural, name), crPatch); err != nil {
+		return fmt.Errorf("patching CR disk size: %w", err)
+	}
+	cli.Infof("%s/%s: CR disk size set to %s", ns, name, *size)
+
+	for _, pvc := range pvcs {
+		pvcName := unstructured.NestedString(pvc, "metadata", "name")
+		patch := map[string]any{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]any{"name": pvcName, "namespace": ns},
+			"spec":       map[string]any{"resources": map[string]any{"requests": map[string]any{"storage": *size}}},
+		}
+		if err := c.Apply(ctx, kube.ResourcePath("v1", ns, "persistentvolumeclaims", pvcName), patch); err != nil {
+			return fmt.Errorf("patching PVC %s: %w", pvcName, err)
+		}
+		cli.Infof("%s/%s: PVC %s resized to %s", ns, name, pvcName, *size)
+	}
+
+	if *skipRestart {
+		cli.Infof("skipping restart; filesystem expansion happens on the next pod restart")
+		return nil
+	}
+	cp := campaign.New([]campaign.Target{{Namespace: ns, Name: name}}, opts)
+	return cp.Run(ctx, c)
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/change"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/diagnostics"
+	"github.com/WalBeh/go-tool-p1/internal/history"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/notify"
+	"github.com/WalBeh/go-tool-p1/internal/objstore"
+	"github.com/WalBeh/go-tool-p1/internal/operator"
+	"github.com/WalBeh/go-tool-p1/internal/prom"
+	"github.com/WalBeh/go-tool-p1/internal/push"
+	"github.com/WalBeh/go-tool-p1/internal/query"
+	"github.com/WalBeh/go-tool-p1/internal/report"
+	"github.com/WalBeh/go-tool-p1/internal/statuspage"
+	"github.com/WalBeh/go-tool-p1/internal/tracing"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "restart",
+		summary: "rolling-restart one or more clusters (namespace/name ...)",
+		run:     runRestart,
+	})
+}
+
+func runRestart(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restart", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "max wait for a pod to return Ready")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "max wait for the cluster to report GREEN")
+	fs.BoolVar(&opts.Force, "force", false, "restart even if the cluster is not GREEN")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "show what would be restarted without deleting pods")
+	simulate := fs.Bool("simulate", false,
+		"estimate per-cluster timelines and total duration from recovery history, without restarting anything")
+	fs.StringVar(&opts.OnTimeout, "on-timeout", opts.OnTimeout,
+		"when GREEN does not return in time: fail, pause (ask to keep waiting), skip (move to the next cluster), or force (ask, then continue)")
+	fromFile := fs.String("from-file", "", "read namespace/name targets from this file, or - for stdin")
+	reportPath := fs.String("report", "", "write a JSON report to this file")
+	reportUpload := fs.String("report-upload", "", "upload the JSON report to s3://, gs:// or az:// destination")
+	otlp := fs.String("otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		"OTLP/HTTP collector base URL for trace export (default $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	historyFile := fs.String("history-file", history.DefaultPath(), "recovery-duration history file (empty to disable)")
+	collectDiag := fs.Bool("collect-diagnostics", false, "on failure, collect a diagnostics tarball per failed cluster")
+	diagDir := fs.String("diagnostics-dir", ".", "directory for diagnostics tarballs")
+	resultsCluster := fs.String("results-cluster", "", "namespace/name of a CrateDB cluster to insert campaign records into")
+	resultsTable := fs.String("results-table", "doc.crt_campaign_log", "table for -results-cluster records")
+	pushgateway := fs.String("pushgateway-url", "", "push campaign metrics to this Prometheus Pushgateway after the run")
+	controlSocket := fs.String("control-socket", "", "Unix socket accepting pause/resume/skip/abort commands during the run")
+	eventsTo := fs.String("events-to", "", "stream NDJSON campaign events to fd:N, unix:PATH, or a file/FIFO path")
+	changeSystem := fs.String("change-system", "", "file a change record around the campaign: jira or servicenow")
+	changeURL := fs.String("change-url", "", "base URL of the change system, e.g. https://example.atlassian.net")
+	changeProject := fs.String("change-project", "", "project key for jira change records, e.g. OPS")
+	statusPage := fs.String("statuspage-page", "", "Statuspage page ID; post a maintenance incident around the campaign")
+	statusURL := fs.String("statuspage-url", "https://api.statuspage.io/v1", "Statuspage API base URL (token from $STATUSPAGE_TOKEN)")
+	fs.StringVar(&opts.ApproveEach, "approve-each-cluster", "",
+		"require sign-off before each cluster: prompt, annotation, or http=ADDR")
+	secondApproval := fs.Bool("second-approval", false,
+		"require a second operator to run `crt approve <campaign-id>` before anything restarts")
+	approvalWindow := fs.Duration("approval-window", 15*time.Minute,
+		"how long to wait for the second approval; older approvals are not honored")
+	fs.IntVar(&opts.Parallel, "parallel", opts.Parallel, "clusters to restart concurrently")
+	fs.StringVar(&opts.MinHealth, "min-health", opts.MinHealth,
+		"health considered good enough to proceed (GREEN or YELLOW); clusters may override via annotations")
+	tierOrder := fs.String("tier-order", "", "comma-separated data pool restart order, e.g. cold,warm,hot")
+	tierMinHealth := fs.String("tier-min-health", "", "per-pool health gates, e.g. hot=GREEN,cold=YELLOW")
+	fs.BoolVar(&opts.QuorumCheck, "quorum-check", false,
+		"before touching pods, verify via SQL that the cluster can lose a node without losing quorum or data copies")
+	fs.BoolVar(&opts.AckDataLoss, "acknowledge-data-loss-risk", false,
+		"proceed even when the quorum check finds tables with number_of_replicas=0")
+	fs.BoolVar(&opts.Flush, "flush-before-restart", false,
+		"flush tables and wait for translog sync before deleting each pod, for faster recovery")
+	fs.StringVar(&opts.RecoveryRate, "recovery-rate", "",
+		"temporarily raise indices.recovery.max_bytes_per_sec to this value (e.g. 200mb) during each cluster's restart")
+	fs.BoolVar(&opts.SnapshotCheck, "snapshot-check", false,
+		"hold off while a snapshot or restore is running; skip the cluster if it outlasts -snapshot-wait")
+	fs.DurationVar(&opts.SnapshotWait, "snapshot-wait", opts.SnapshotWait,
+		"how long -snapshot-check waits for running snapshots before skipping the cluster")
+	fs.BoolVar(&opts.BlockCheck, "write-block-check", false,
+		"refuse clusters with read_only_allow_delete tables or disabled shard allocation")
+	fs.BoolVar(&opts.CapacityCheck, "capacity-check", false,
+		"before deleting each pod, verify a node could schedule its replacement; hold until one can")
+	fs.DurationVar(&opts.CapacityWait, "capacity-wait", opts.CapacityWait,
+		"how long -capacity-check holds for capacity (e.g. an autoscaler node) before failing the cluster")
+	fs.StringVar(&opts.Reason, "reason", "",
+		"why these clusters are being rolled, e.g. 'CVE-2024-1234 kernel patch'; recorded in annotations, Events, reports and notifications")
+	fs.BoolVar(&opts.PreemptionCheck, "preemption-check", false,
+		"show each pod's priority class, warn on preemption risk, and abort when recreating it would preempt critical system pods")
+	fs.DurationVar(&opts.PostReadySoak, "post-ready-soak", 0,
+		"after each pod is Ready and health is back, watch for this long before moving on")
+	fs.Float64Var(&opts.LatencyFactor, "latency-threshold", 0,
+		"abort the cluster when average query latency grows beyond this factor after a pod restart, e.g. 1.5 (0 = off)")
+	promGate := fs.String("prom-gate", "",
+		"PromQL condition checked before each pod restart, e.g. 'sum(rate(errors[5m])) < 0.1'")
+	fs.StringVar(&opts.PromURL, "prom-url", os.Getenv("PROMETHEUS_URL"),
+		"Prometheus base URL for -prom-gate (default $PROMETHEUS_URL)")
+	fs.BoolVar(&opts.FairShare, "fair-share", false, "interleave clusters round-robin across namespaces")
+	fs.BoolVar(&opts.EffortSort, "order-by-effort", false,
+		"start the clusters with the most primary shard bytes per node first (measured via SQL)")
+	fs.DurationVar(&opts.PodPause, "pod-pause", 0, "minimum pause between pods of a cluster")
+	fs.Float64Var(&opts.AdaptivePace, "adaptive-pace", 0,
+		"wait this fraction of the previous pod's recovery time before the next pod, e.g. 0.5")
+	fs.IntVar(&opts.VerifyRetries, "verify-retries", 0,
+		"retry a pod's post-restart verification this many times on transient failure")
+	podSel := fs.String("pods", "",
+		"restart only these pods: comma-separated names, ordinals, or ranges like 0-2")
+	skipPods := fs.String("skip-pods", "",
+		"leave these pods alone (same syntax as -pods), e.g. one still mid-recovery")
+	fs.BoolVar(&opts.VerifyRev, "verify-revision", false,
+		"after each cluster, fail if any pod still runs an old StatefulSet revision")
+	fs.BoolVar(&opts.NodeCount, "verify-node-count", false,
+		"after each pod, verify via SQL that it rejoined and sys.nodes shows every node")
+	fs.BoolVar(&opts.SplitBrain, "split-brain-check", false,
+		"before each cluster, ask every node for its elected master and abort on disagreement")
+	fs.BoolVar(&opts.ProbeHTTP, "probe-http", false,
+		"after each pod turns Ready, also require its HTTP admin port to answer")
+	healthPath := fs.String("health-path", "",
+		"dotted path to the CR health field for forked operator CRDs (default status.crateDBStatus.health)")
+	greenValues := fs.String("green-values", "",
+		"comma-separated health values treated as GREEN, e.g. GREEN,OK")
+	fs.BoolVar(&opts.Unmanaged, "unmanaged", false,
+		"targets are plain StatefulSets without a CrateDB CR (e.g. Helm installs); gate on SQL/HTTP health only")
+	selector := fs.String("selector", "",
+		"with -unmanaged, restart every StatefulSet matching this label selector instead of naming targets, e.g. app=crate")
+	policy := fs.String("policy", "",
+		"per-cluster preflight policy over CR/StatefulSet/SQL fields, e.g. 'nodes >= 3 && !spec.backups.disabled'")
+	fs.BoolVar(&opts.Explain, "explain", false,
+		"print the inputs behind every skip/proceed/wait decision as structured key=value lines")
+	breakGlass := fs.Bool("break-glass", false,
+		"override the protected-cluster guard after typing each protected cluster's name")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	switch opts.OnTimeout {
+	case campaign.TimeoutFail, campaign.TimeoutPause, campaign.TimeoutSkip, campaign.TimeoutForce:
+	default:
+		return fmt.Errorf("invalid -on-timeout %q (want fail, pause, skip, or force)", opts.OnTimeout)
+	}
+	if !cratedb.KnownHealth(opts.MinHealth) {
+		return fmt.Errorf("invalid -min-health %q", opts.MinHealth)
+	}
+	if *promGate != "" {
+		if opts.PromURL == "" {
+			return fmt.Errorf("-prom-gate needs -prom-url or $PROMETHEUS_URL")
+		}
+		gate, err := prom.ParseGate(*promGate)
+		if err != nil {
+			return err
+		}
+		opts.PromGate = gate
+	}
+	if *tierOrder != "" {
+		opts.TierOrder = strings.Split(*tierOrder, ",")
+	}
+	if *tierMinHealth != "" {
+		opts.TierMinHealth = map[string]string{}
+		for _, pair := range strings.Split(*tierMinHealth, ",") {
+			pool, health, found := strings.Cut(pair, "=")
+			if !found || !cratedb.KnownHealth(health) {
+				return fmt.Errorf("invalid -tier-min-health entry %q (want pool=GREEN|YELLOW)", pair)
+			}
+			opts.TierMinHealth[pool] = health
+		}
+	}
+
+	if opts.EffortSort && opts.FairShare {
+		return fmt.Errorf("-order-by-effort and -fair-share are mutually exclusive")
+	}
+	if opts.Unmanaged {
+		switch {
+		case opts.QuorumCheck, opts.EffortSort, opts.VerifyRev, opts.NodeCount, opts.SplitBrain,
+			opts.Flush, opts.SnapshotCheck, opts.BlockCheck,
+			opts.RecoveryRate != "", opts.LatencyFactor > 0, *tierOrder != "":
+			return fmt.Errorf("-unmanaged supports only the SQL/HTTP health gates; checks relying on operator conventions cannot be combined with it")
+		}
+	}
+	if *selector != "" && !opts.Unmanaged {
+		return fmt.Errorf("-selector requires -unmanaged")
+	}
+	if *policy != "" {
+		if opts.Unmanaged {
+			return fmt.Errorf("-policy needs CR fields and cannot be combined with -unmanaged")
+		}
+		expr, err := query.Parse(*policy)
+		if err != nil {
+			return fmt.Errorf("parsing -policy: %w", err)
+		}
+		opts.Policy = expr
+	}
+	if *podSel != "" {
+		opts.Pods = strings.Split(*podSel, ",")
+	}
+	if *skipPods != "" {
+		opts.SkipPods = strings.Split(*skipPods, ",")
+	}
+
+	targetArgs := fs.Args()
+	if *fromFile != "" {
+		fileTargets, err := readTargetFile(*fromFile)
+		if err != nil {
+			return err
+		}
+		targetArgs = append(targetArgs, fileTargets...)
+	}
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	var targets []campaign.Target
+	if *selector != "" {
+		if len(targetArgs) > 0 {
+			return fmt.Errorf("-selector and explicit targets are mutually exclusive")
+		}
+		targets, err = selectorTargets(ctx, c, g.ResolveNamespace(c), *selector)
+	} else {
+		targets, err = parseTargets(targetArgs, &g)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *simulate {
+		return simulateCampaign(ctx, c, targets, opts, *historyFile)
+	}
+	if !opts.DryRun {
+		if err := checkProtected(ctx, c, targets, *breakGlass, opts.Unmanaged); err != nil {
+			return err
+		}
+	}
+
+	if *historyFile != "" {
+		if entries, err := history.Read(*historyFile); err == nil {
+			opts.PodEstimate = history.PodAverage(entries)
+		}
+	}
+	fileCfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	opts.TenantLabels = fileCfg.TenantKeys()
+	if !opts.Unmanaged {
+		if v, err := operator.Detect(ctx, c); err != nil {
+			cli.Debugf("operator detection: %v", err)
+		} else {
+			operator.Apply(v)
+		}
+	}
+	cratedb.SetHealthMapping(fileCfg.HealthPath, fileCfg.GreenValues)
+	if *healthPath != "" || *greenValues != "" {
+		cratedb.SetHealthMapping(*healthPath, strings.Split(*greenValues, ","))
+	}
+	cp := campaign.New(targets, opts)
+	cp.Operator = cli.Identity(c)
+	cp.Tracer = tracing.New(*otlp)
+	if *controlSocket != "" {
+		cp.Control = campaign.NewControl()
+		if err := cp.Control.Listen(ctx, *controlSocket); err != nil {
+			return err
+		}
+	}
+	if *eventsTo != "" {
+		stream, err := campaign.OpenEventStream(*eventsTo)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		cp.Stream = stream
+	}
+	if id := cp.Tracer.TraceID(); id != "" {
+		cli.Debugf("trace id %s", id)
+	}
+	if *secondApproval && !opts.DryRun {
+		if err := cp.AwaitSecondApproval(ctx, c, *approvalWindow); err != nil {
+			return err
+		}
+	}
+	var ticket string
+	var changes change.System
+	if *changeSystem != "" && !opts.DryRun {
+		changes, err = change.New(*changeSystem, *changeURL, *changeProject)
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.String()
+		}
+		id, err := changes.Create(ctx,
+			fmt.Sprintf("Rolling restart of %d CrateDB cluster(s)", len(targets)),
+			fmt.Sprintf("Campaign %s restarts the following clusters:\n%s", cp.ID, strings.Join(names, "\n")))
+		if err != nil {
+			cli.Warnf("%v", err)
+		} else {
+			ticket = id
+			cli.Infof("change record %s created", ticket)
+		}
+	}
+	var maintenance string
+	var sp *statuspage.Client
+	if *statusPage != "" && !opts.DryRun {
+		sp = statuspage.New(*statusURL, *statusPage, os.Getenv("STATUSPAGE_TOKEN"))
+		names := make([]string, len(targets))
+		for i, t := range targets {
+			names[i] = t.String()
+		}
+		id, err := sp.StartMaintenance(ctx,
+			fmt.Sprintf("Rolling restart of %d CrateDB cluster(s)", len(targets)),
+			"Clusters: "+strings.Join(names, ", "))
+		if err != nil {
+			cli.Warnf("%v", err)
+		} else {
+			maintenance = id
+		}
+	}
+	started := time.Now()
+	runErr := cp.Run(ctx, c)
+	if maintenance != "" {
+		outcome := "completed"
+		if runErr != nil {
+			outcome = "finished with errors: " + runErr.Error()
+		}
+		body := fmt.Sprintf("Maintenance %s after %s.", outcome, time.Since(started).Round(time.Second))
+		if err := sp.Resolve(ctx, maintenance, body); err != nil {
+			cli.Warnf("%v", err)
+		}
+	}
+	if len(fileCfg.TenantWebhooks) > 0 && !opts.DryRun {
+		router := notify.NewRouter(fileCfg.TenantWebhooks)
+		for _, res := range cp.Results {
+			text := fmt.Sprintf("campaign %s: %s/%s restart %s", cp.ID, res.Namespace, res.Name, res.Status)
+			switch {
+			case res.Status == "succeeded":
+				text += fmt.Sprintf(" (%d pod(s) in %s)", len(res.Pods), res.Finished.Sub(res.Started).Round(time.Second))
+			case res.Error != "":
+				text += ": " + res.Error
+			}
+			if opts.Reason != "" {
+				text += " — reason: " + opts.Reason
+			}
+			if err := router.Send(ctx, res.Tenant, text); err != nil {
+				cli.Warnf("%v", err)
+			}
+		}
+	}
+	if ticket != "" {
+		rep := report.FromCampaign(cp, started, time.Now())
+		body := fmt.Sprintf("Campaign %s finished after %s.", cp.ID, time.Since(started).Round(time.Second))
+		if runErr != nil {
+			body += "\nResult: " + runErr.Error()
+		}
+		if b, err := rep.JSON(); err == nil {
+			body += "\n\n{code}\n" + string(b) + "\n{code}"
+		}
+		if err := changes.Comment(ctx, ticket, body); err != nil {
+			cli.Warnf("%v", err)
+		}
+	}
+	if *collectDiag {
+		for _, res := range cp.Results {
+			if res.Status != "failed" {
+				continue
+			}
+			path, err := diagnostics.Collect(ctx, c, res.Namespace, res.Name, *diagDir)
+			if err != nil {
+				cli.Warnf("%v", err)
+				continue
+			}
+			cli.Infof("diagnostics for %s/%s written to %s", res.Namespace, res.Name, path)
+		}
+	}
+	if *reportPath != "" || *reportUpload != "" {
+		rep := report.FromCampaign(cp, started, time.Now())
+		if *reportPath != "" {
+			if err := rep.WriteFile(*reportPath); err != nil {
+				cli.Warnf("%v", err)
+			}
+		}
+		if *reportUpload != "" {
+			if b, err := rep.JSON(); err != nil {
+				cli.Warnf("%v", err)
+			} else if err := objstore.Upload(ctx, *reportUpload, cp.ID+".json", b); err != nil {
+				cli.Warnf("%v", err)
+			} else {
+				cli.Infof("report uploaded to %s/%s.json", *reportUpload, cp.ID)
+			}
+		}
+	}
+	if *historyFile != "" && !opts.DryRun {
+		if err := history.Append(*historyFile, historyEntries(cp)); err != nil {
+			cli.Warnf("%v", err)
+		}
+	}
+	if *pushgateway != "" && !opts.DryRun {
+		if err := push.Campaign(ctx, *pushgateway, cp, started, time.Now()); err != nil {
+			cli.Warnf("%v", err)
+		}
+	}
+	if *resultsCluster != "" && !opts.DryRun {
+		ns, name, found := strings.Cut(*resultsCluster, "/")
+		if !found {
+			cli.Warnf("-results-cluster must be namespace/name, got %q", *resultsCluster)
+		} else if err := report.WriteToCrateDB(ctx, c, ns, name, *resultsTable, cp); err != nil {
+			cli.Warnf("writing results to CrateDB: %v", err)
+		}
+	}
+	return runErr
+}
+
+// readTargetFile reads namespace/name entries, one per line, from a
+// file or stdin ("-"). Blank lines and #-comments are skipped so the
+// output of restart-needed (or any other tooling) can be piped in
+// directly.
+func readTargetFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading target file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	var targets []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	return targets, sc.Err()
+}
+
+// selectorTargets lists StatefulSets matching the label selector and
+// turns them into campaign targets, for -unmanaged runs that discover
+// clusters by label instead of by name.
+func selectorTargets(ctx context.Context, c *kube.Client, namespace, selector string) ([]campaign.Target, error) {
+	stss, err := c.List(ctx, kube.ResourcePath("apps/v1", namespace, "statefulsets", ""),
+		url.Values{"labelSelector": []string{selector}})
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]campaign.Target, 0, len(stss))
+	for _, sts := range stss {
+		targets = append(targets, campaign.Target{
+			Namespace: unstructured.NestedString(sts, "metadata", "namespace"),
+			Name:      unstructured.NestedString(sts, "metadata", "name"),
+		})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no statefulsets match -selector %s", selector)
+	}
+	return targets, nil
+}
+
+// parseTargets turns "namespace/name" arguments into targets; a bare
+// name uses the namespace from the global flags or context.
+func parseTargets(args []string, g *cli.GlobalFlags) ([]campaign.Target, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no clusters given; expected namespace/name arguments")
+	}
+	targets := make([]campaign.Target, 0, len(args))
+	for _, a := range args {
+		ns, name, found := strings.Cut(a, "/")
+		if !found {
+			if g.Namespace == "" {
+				return nil, fmt.Errorf("target %q has no namespace and -namespace is not set", a)
+			}
+			ns, name = g.Namespace, a
+		}
+		if ns == "" || name == "" {
+			return nil, fmt.Errorf("invalid target %q", a)
+		}
+		targets = append(targets, campaign.Target{Namespace: ns, Name: name})
+	}
+	return targets, nil
+}
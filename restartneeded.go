@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "restart-needed",
+		summary: "list clusters whose pods are stale and need a restart",
+		run:     runRestartNeeded,
+	})
+}
+
+func runRestartNeeded(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restart-needed", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	maxUptime := fs.Duration("max-uptime", 0, "flag clusters with pods older than this (0 disables)")
+	explain := fs.Bool("explain", false, "print the reason for each cluster to stderr")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	clusters, err := cratedb.List(ctx, c, g.ResolveNamespace(c))
+	if err != nil {
+		return err
+	}
+
+	rw := newRefWatcher()
+	for _, cl := range clusters {
+		reason, err := restartReason(ctx, c, rw, cl, *maxUptime)
+		if err != nil {
+			cli.Warnf("%s/%s: %v", cl.Namespace, cl.Name, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		// Plain namespace/name lines, consumable by restart -from-file.
+		fmt.Printf("%s/%s\n", cl.Namespace, cl.Name)
+		if *explain {
+			cli.Infof("%s/%s: %s", cl.Namespace, cl.Name, reason)
+		}
+	}
+	return nil
+}
+
+// restartReason returns why the cluster needs a restart, or "".
+func restartReason(ctx context.Context, c *kube.Client, rw *refWatcher, cl cratedb.Cluster, maxUptime time.Duration) (string, error) {
+	pods, err := cratedb.Pods(ctx, c, cl)
+	if err != nil {
+		return "", err
+	}
+	if len(pods) == 0 {
+		return "", nil
+	}
+
+	oldestStart := time.Time{}
+	for _, pod := range pods {
+		start := parseK8sTime(unstructured.NestedString(pod, "status", "startTime"))
+		if start.IsZero() {
+			continue
+		}
+		if oldestStart.IsZero() || start.Before(oldestStart) {
+			oldestStart = start
+		}
+		// Image older than spec?
+		for _, cv := range unstructured.NestedSlice(pod, "spec", "containers") {
+			container, ok := cv.(map[string]any)
+			if !ok || unstructured.NestedString(container, "name") != "crate" {
+				continue
+			}
+			tag := imageTag(unstructured.NestedString(container, "image"))
+			if want := cl.Version(); want != "" && tag != want {
+				return fmt.Sprintf("pod %s runs image tag %s, spec wants %s",
+					unstructured.NestedString(pod, "metadata", "name"), tag, want), nil
+			}
+		}
+	}
+
+	if maxUptime > 0 && !oldestStart.IsZero() && time.Since(oldestStart) > maxUptime {
+		return fmt.Sprintf("oldest pod started %s ago (max-uptime %s)",
+			time.Since(oldestStart).Round(time.Hour), maxUptime), nil
+	}
+
+	// Pods predating changes to referenced Secrets/ConfigMaps.
+	stsIndex, err := cratedb.StatefulSets(ctx, c, []cratedb.Cluster{cl})
+	if err != nil {
+		return "", err
+	}
+	hashes, err := rw.referenceHashes(ctx, c, cl, stsIndex[cl.Namespace+"/"+cl.Name])
+	if err != nil {
+		return "", err
+	}
+	for name, modTime := range referenceModTimes(ctx, c, cl, hashes) {
+		if !oldestStart.IsZero() && modTime.After(oldestStart) {
+			return fmt.Sprintf("%s changed %s, after the oldest pod started", name, modTime.Format(time.RFC3339)), nil
+		}
+	}
+	return "", nil
+}
+
+// referenceModTimes resolves the last-modified time of each referenced
+// object, taken from the newest managedFields entry (falling back to
+// creationTimestamp).
+func referenceModTimes(ctx context.Context, c *kube.Client, cl cratedb.Cluster, refs map[string]string) map[string]time.Time {
+	times := map[string]time.Time{}
+	for ref := range refs {
+		kind, name, found := splitRef(ref)
+		if !found {
+			continue
+		}
+		var obj map[string]any
+		if err := c.Get(ctx, kube.ResourcePath("v1", cl.Namespace, kind+"s", name), &obj); err != nil {
+			continue
+		}
+		t := parseK8sTime(unstructured.NestedString(obj, "metadata", "creationTimestamp"))
+		for _, mf := range unstructured.NestedSlice(obj, "metadata", "managedFields") {
+			entry, ok := mf.(map[string]any)
+			if !ok {
+				continue
+			}
+			if mt := parseK8sTime(unstructured.NestedString(entry, "time")); mt.After(t) {
+				t = mt
+			}
+		}
+		if !t.IsZero() {
+			times[ref] = t
+		}
+	}
+	return times
+}
+
+func splitRef(ref string) (kind, name string, ok bool) {
+	for i := 0; i < len(ref); i++ {
+		if ref[i] == '/' {
+			return ref[:i], ref[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func parseK8sTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
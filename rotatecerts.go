@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/certs"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "rotate-certs",
+		summary: "restart a cluster so pods pick up rotated TLS certificates",
+		run:     runRotateCerts,
+	})
+}
+
+func runRotateCerts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rotate-certs", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	regenerate := fs.Bool("regenerate", false, "delete the TLS secrets first so the issuer recreates them")
+	yes := fs.Bool("yes", false, "do not prompt before deleting secrets")
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "max wait for a pod to return Ready")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "max wait for the cluster to report GREEN")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt rotate-certs [namespace/]name")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+
+	before, err := certs.ForCluster(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	if len(before) == 0 {
+		return fmt.Errorf("no TLS secrets found for %s/%s", ns, name)
+	}
+	printCerts("before", before)
+
+	if *regenerate {
+		for _, in := range before {
+			if !*yes && !cli.Confirm(fmt.Sprintf("delete TLS secret %s (the issuer must recreate it)", in.Secret)) {
+				return fmt.Errorf("aborted")
+			}
+			if err := c.Delete(ctx, kube.ResourcePath("v1", ns, "secrets", in.Secret)); err != nil {
+				return fmt.Errorf("deleting secret %s: %w", in.Secret, err)
+			}
+			cli.Infof("deleted secret %s", in.Secret)
+		}
+		if err := waitSecretsChanged(ctx, c, cl, before); err != nil {
+			return err
+		}
+	} else {
+		// Without -regenerate the secrets should already contain new
+		// material; warn when nothing actually changed recently.
+		cli.Infof("restarting so pods load the current secret contents")
+	}
+
+	cp := campaign.New([]campaign.Target{{Namespace: ns, Name: name}}, opts)
+	if err := cp.Run(ctx, c); err != nil {
+		return err
+	}
+
+	after, err := certs.ForCluster(ctx, c, cl)
+	if err != nil {
+		return err
+	}
+	printCerts("after", after)
+	if certs.SoonestExpiry(after).Equal(certs.SoonestExpiry(before)) && *regenerate {
+		cli.Warnf("certificate expiry unchanged; the issuer may not have rotated the secrets")
+	}
+	return nil
+}
+
+// waitSecretsChanged polls until every previously seen secret exists
+// again with different content.
+func waitSecretsChanged(ctx context.Context, c *kube.Client, cl cratedb.Cluster, before []certs.Info) error {
+	old := map[string]string{}
+	for _, in := range before {
+		old[in.Secret] = in.Hash
+	}
+	deadline := time.Now().Add(5 * time.Minute)
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for TLS secrets to be recreated")
+		}
+		now, err := certs.ForCluster(ctx, c, cl)
+		if err != nil {
+			return err
+		}
+		changed := 0
+		for _, in := range now {
+			if h, ok := old[in.Secret]; ok && h != in.Hash {
+				changed++
+			}
+		}
+		if changed == len(old) {
+			cli.Infof("all %d TLS secret(s) recreated", changed)
+			return nil
+		}
+		if err := cli.Sleep(ctx, kube.WaitBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+func printCerts(phase string, infos []certs.Info) {
+	for _, in := range infos {
+		expiry := "unparsed"
+		if !in.NotAfter.IsZero() {
+			expiry = fmt.Sprintf("%s (%dd)", in.NotAfter.Format("2006-01-02"), int(time.Until(in.NotAfter).Hours()/24))
+		}
+		cli.Infof("%s: secret %s cn=%s expires %s", phase, in.Secret, in.Subject, expiry)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "rotate-password",
+		summary: "rotate a CrateDB user's password and its Kubernetes secret",
+		run:     runRotatePassword,
+	})
+}
+
+func runRotatePassword(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rotate-password", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	user := fs.String("user", "crate", "CrateDB user to rotate")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt rotate-password [namespace/]name [-user=crate]")
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+
+	newPassword, err := generatePassword()
+	if err != nil {
+		return err
+	}
+
+	// Change the password in CrateDB first: if the SQL fails, the
+	// secret still holds working credentials.
+	sql, err := cratesql.Connect(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+	defer sql.Close()
+	stmt := fmt.Sprintf(`ALTER USER "%s" SET (password = ?)`, *user)
+	if _, err := sql.Exec(ctx, stmt, newPassword); err != nil {
+		return fmt.Errorf("setting password for %s: %w", *user, err)
+	}
+	cli.Infof("%s/%s: password changed for user %s", ns, name, *user)
+
+	secretName := cratesql.UserSecretName(name, *user)
+	patch := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]any{"name": secretName, "namespace": ns},
+		"data": map[string]any{
+			"password": base64.StdEncoding.EncodeToString([]byte(newPassword)),
+		},
+	}
+	if err := c.Apply(ctx, kube.ResourcePath("v1", ns, "secrets", secretName), patch); err != nil {
+		return fmt.Errorf("password changed in CrateDB but updating secret %s failed: %w; update it manually", secretName, err)
+	}
+	cli.Infof("%s/%s: secret %s updated", ns, name, secretName)
+
+	// Verify end to end with the new credentials.
+	check, err := cratesql.ConnectWith(ctx, c, ns, name, *user, newPassword)
+	if err != nil {
+		return fmt.Errorf("verifying new credentials: %w", err)
+	}
+	defer check.Close()
+	if err := check.Ping(ctx); err != nil {
+		return fmt.Errorf("verifying new credentials: %w", err)
+	}
+	cli.Infof("%s/%s: new credentials verified", ns, name)
+	return nil
+}
+
+// generatePassword returns a 32-character URL-safe random password.
+func generatePassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+)
+
+const defaultReleaseAPI = "https://api.github.com/repos/WalBeh/go-tool-p1/releases/latest"
+
+func init() {
+	register(command{
+		name:    "self-update",
+		summary: "download and install the latest release of this tool",
+		run:     runSelfUpdate,
+	})
+}
+
+func runSelfUpdate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	releaseAPI := fs.String("release-url", defaultReleaseAPI, "GitHub-style latest-release API endpoint")
+	checkOnly := fs.Bool("check", false, "only report whether a newer release exists")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	rel, err := fetchRelease(ctx, *releaseAPI)
+	if err != nil {
+		return err
+	}
+	if rel.TagName == version || rel.TagName == "v"+version {
+		cli.Infof("already up to date (%s)", version)
+		return nil
+	}
+	cli.Infof("current %s, latest %s", version, rel.TagName)
+	if *checkOnly {
+		return nil
+	}
+
+	assetName := fmt.Sprintf("crt_%s_%s", runtime.GOOS, runtime.GOARCH)
+	var assetURL, sumsURL string
+	for _, a := range rel.Assets {
+		switch {
+		case a.Name == assetName:
+			assetURL = a.URL
+		case a.Name == "checksums.txt":
+			sumsURL = a.URL
+		}
+	}
+	if assetURL == "" {
+		return fmt.Errorf("release %s has no asset %s", rel.TagName, assetName)
+	}
+	if sumsURL == "" {
+		return fmt.Errorf("release %s has no checksums.txt; refusing unverified update", rel.TagName)
+	}
+
+	binary, err := download(ctx, assetURL)
+	if err != nil {
+		return err
+	}
+	sums, err := download(ctx, sumsURL)
+	if err != nil {
+		return err
+	}
+	if err := verifyChecksum(binary, string(sums), assetName); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+	// Write next to the current binary so the final rename is atomic
+	// and never leaves a half-written executable in PATH.
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, binary, 0o755); err != nil {
+		return fmt.Errorf("writing new binary: %w", err)
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	cli.Infof("updated %s to %s", exe, rel.TagName)
+	return nil
+}
+
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func fetchRelease(ctx context.Context, url string) (*release, error) {
+	body, err := download(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("checking releases: %w", err)
+	}
+	var rel release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return nil, fmt.Errorf("parsing release metadata: %w", err)
+	}
+	if rel.TagName == "" {
+		return nil, fmt.Errorf("release metadata has no tag_name")
+	}
+	return &rel, nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verifyChecksum checks data against the sha256 listed for name in a
+// standard checksums.txt ("<hex>  <name>" per line).
+func verifyChecksum(data []byte, sums, name string) error {
+	sum := sha256.Sum256(data)
+	want := ""
+	for _, line := range strings.Split(sums, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == name {
+			want = fields[0]
+			break
+		}
+	}
+	if want == "" {
+		return fmt.Errorf("checksums.txt has no entry for %s", name)
+	}
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", name, got, want)
+	}
+	return nil
+}
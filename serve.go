@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/config"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/history"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "serve",
+		summary: "run as a daemon that polls fleet health and serves it over HTTP",
+		run:     runServe,
+	})
+}
+
+// fleetState is the last snapshot taken by the serve loop.
+type fleetState struct {
+	mu       sync.RWMutex
+	taken    time.Time
+	clusters []fleetEntry
+}
+
+type fleetEntry struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Tenant    string `json:"tenant,omitempty"`
+	Version   string `json:"version"`
+	Nodes     int64  `json:"nodes"`
+	Health    string `json:"health"`
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	listen := fs.String("listen", ":8080", "address to serve HTTP on")
+	interval := fs.Duration("interval", time.Minute, "fleet poll interval")
+	enablePprof := fs.Bool("pprof", false, "expose net/http/pprof and runtime stats under /debug/")
+	transitionsFile := fs.String("transitions-file", history.DefaultTransitionsPath(),
+		"record health transitions to this file (empty to disable)")
+	leaderElect := fs.Bool("leader-elect", false, "acquire a Lease before acting, for multi-replica deployments")
+	campaignSocket := fs.String("campaign-control-socket", "",
+		"pause/resume the campaign on this control socket from Alertmanager webhooks posted to /alerts")
+	alertMatch := fs.String("alert-match", "severity=critical",
+		"label matchers an alert must carry to pause the campaign, e.g. severity=critical,team=db")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	namespace := g.ResolveNamespace(c)
+	if *leaderElect {
+		if err := acquireLease(ctx, c, c.Namespace()); err != nil {
+			return err
+		}
+	}
+
+	state := &fleetState{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/fleet", func(w http.ResponseWriter, r *http.Request) {
+		state.mu.RLock()
+		defer state.mu.RUnlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"taken":    state.taken,
+			"clusters": state.clusters,
+		})
+	})
+	mux.HandleFunc("/metrics", state.metrics)
+
+	if *campaignSocket != "" {
+		matchers, err := parseMatchers(*alertMatch)
+		if err != nil {
+			return err
+		}
+		mux.HandleFunc("/alerts", newAlertPauser(*campaignSocket, matchers).handle)
+		cli.Infof("accepting Alertmanager webhooks on /alerts (matchers: %s)", *alertMatch)
+	}
+
+	if *enablePprof {
+		registerDebugHandlers(mux)
+		cli.Infof("pprof enabled under /debug/pprof/")
+	}
+
+	srv := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	fileCfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cratedb.SetHealthMapping(fileCfg.HealthPath, fileCfg.GreenValues)
+	go pollFleet(ctx, c, namespace, *interval, state, newRefWatcher(), *transitionsFile, fileCfg.TenantKeys())
+
+	cli.Infof("serving on %s", *listen)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// metrics renders the last fleet snapshot in Prometheus text format:
+// a health gauge per cluster (0 UNREACHABLE through 3 GREEN) and the
+// fleet size, the daemon-side counterpart of the pushgateway campaign
+// metrics.
+func (s *fleetState) metrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# TYPE crt_cluster_health gauge")
+	for _, e := range s.clusters {
+		fmt.Fprintf(w, "crt_cluster_health{namespace=%q,name=%q,tenant=%q} %d\n",
+			e.Namespace, e.Name, e.Tenant, cratedb.HealthRank(e.Health))
+	}
+	fmt.Fprintln(w, "# TYPE crt_fleet_clusters gauge")
+	fmt.Fprintf(w, "crt_fleet_clusters %d\n", len(s.clusters))
+}
+
+func pollFleet(ctx context.Context, c *kube.Client, namespace string, interval time.Duration, state *fleetState, refs *refWatcher, transitionsFile string, tenantKeys []string) {
+	lastHealth := map[string]string{}
+	for {
+		clusters, err := cratedb.List(ctx, c, namespace)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			cli.Warnf("fleet poll: %v", err)
+		} else {
+			entries := make([]fleetEntry, 0, len(clusters))
+			for _, cl := range clusters {
+				health := cl.Health()
+				key := cl.Namespace + "/" + cl.Name
+				if prev, seen := lastHealth[key]; seen && prev != health && transitionsFile != "" {
+					if err := history.AppendTransition(transitionsFile, history.Transition{
+						Time:      time.Now(),
+						Namespace: cl.Namespace,
+						Cluster:   cl.Name,
+						From:      prev,
+						To:        health,
+					}); err != nil {
+						cli.Warnf("%v", err)
+					}
+				}
+				lastHealth[key] = health
+				entries = append(entries, fleetEntry{
+					Namespace: cl.Namespace,
+					Name:      cl.Name,
+					Tenant:    cl.Tenant(tenantKeys),
+					Version:   cl.Version(),
+					Nodes:     cl.DataNodes(),
+					Health:    health,
+				})
+			}
+			state.mu.Lock()
+			state.taken = time.Now()
+			state.clusters = entries
+			state.mu.Unlock()
+			refs.check(ctx, c, clusters)
+		}
+		if err := cli.Sleep(ctx, interval); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "settings",
+		summary: "view or patch CR spec settings: get|set",
+		run:     runSettings,
+	})
+}
+
+func runSettings(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("settings", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	wait := fs.Duration("wait", 0, "after set, wait this long for the operator rollout to finish (0 = don't wait)")
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: crt settings get|set [namespace/]cluster [key[=value] ...]")
+	}
+	verb := args[0]
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: crt settings %s [namespace/]cluster ...", verb)
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, name, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, name = c.Namespace(), fs.Arg(0)
+	}
+	cl, err := cratedb.Get(ctx, c, ns, name)
+	if err != nil {
+		return err
+	}
+
+	switch verb {
+	case "get":
+		return settingsGet(cl, fs.Args()[1:])
+	case "set":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("usage: crt settings set [namespace/]cluster key=value ...")
+		}
+		return settingsSet(ctx, c, cl, fs.Args()[1:], *wait)
+	default:
+		return fmt.Errorf("unknown settings subcommand %q", verb)
+	}
+}
+
+func settingsGet(cl cratedb.Cluster, keys []string) error {
+	spec := unstructured.NestedMap(cl.Obj, "spec")
+	flat := map[string]any{}
+	flatten("", spec, flat)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if len(keys) == 0 {
+		sorted := make([]string, 0, len(flat))
+		for k := range flat {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			fmt.Fprintf(w, "%s\t%v\n", k, flat[k])
+		}
+		return w.Flush()
+	}
+	for _, k := range keys {
+		v, ok := flat[k]
+		if !ok {
+			return fmt.Errorf("spec has no key %q", k)
+		}
+		fmt.Fprintf(w, "%s\t%v\n", k, v)
+	}
+	return w.Flush()
+}
+
+func settingsSet(ctx context.Context, c *kube.Client, cl cratedb.Cluster, pairs []string, wait time.Duration) error {
+	spec := map[string]any{}
+	for _, pair := range pairs {
+		key, raw, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		setNested(spec, strings.Split(key, "."), parseScalar(raw))
+	}
+	apply := map[string]any{
+		"apiVersion": cratedb.APIVersion,
+		"kind":       "CrateDB",
+		"metadata": map[string]any{
+			"name":      cl.Name,
+			"namespace": cl.Namespace,
+		},
+		"spec": spec,
+	}
+	path := kube.ResourcePath(cratedb.APIVersion, cl.Namespace, cratedb.Plural, cl.Name)
+	if err := c.Apply(ctx, path, apply); err != nil {
+		return fmt.Errorf("applying settings: %w", err)
+	}
+	cli.Infof("%s/%s: settings applied", cl.Namespace, cl.Name)
+	if wait == 0 {
+		return nil
+	}
+	return waitRollout(ctx, c, cl.Namespace, cl.Name, wait)
+}
+
+// waitRollout waits until all crate pods are Ready and the cluster
+// reports GREEN, covering the restart the operator may perform after
+// a spec change.
+func waitRollout(ctx context.Context, c *kube.Client, ns, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	// Give the operator a moment to begin reconciling before we start
+	// checking for steady state.
+	if err := cli.Sleep(ctx, 10*time.Second); err != nil {
+		return err
+	}
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for rollout", timeout)
+		}
+		cl, err := cratedb.Get(ctx, c, ns, name)
+		if err != nil {
+			return err
+		}
+		if cl.Health() == cratedb.HealthGreen {
+			pods, err := cratedb.Pods(ctx, c, cl)
+			if err != nil {
+				return err
+			}
+			ready := 0
+			for _, p := range pods {
+				if cratedb.PodReady(p) {
+					ready++
+				}
+			}
+			if len(pods) > 0 && ready == len(pods) {
+				cli.Infof("%s/%s: rollout complete (%d pod(s) ready, GREEN)", ns, name, ready)
+				return nil
+			}
+		}
+		if err := cli.Sleep(ctx, kube.WaitBackoff); err != nil {
+			return err
+		}
+	}
+}
+
+func flatten(prefix string, m map[string]any, out map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flatten(key, val, out)
+		case []any:
+			b, _ := json.Marshal(val)
+			out[key] = string(b)
+		default:
+			out[key] = val
+		}
+	}
+}
+
+func setNested(m map[string]any, path []string, value any) {
+	for i, p := range path {
+		if i == len(path)-1 {
+			m[p] = value
+			return
+		}
+		next, ok := m[p].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			m[p] = next
+		}
+		m = next
+	}
+}
+
+// parseScalar interprets raw as JSON when possible (numbers, bools,
+// objects) and falls back to the plain string.
+func parseScalar(raw string) any {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err == nil {
+		return v
+	}
+	return raw
+}
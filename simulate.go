@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/history"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+// simulateFallbackPod is the per-pod estimate used when the history
+// file has no data for a cluster at all.
+const simulateFallbackPod = 4 * time.Minute
+
+// simulateCampaign prints a per-cluster timeline and total duration
+// estimate for the given targets without touching the API beyond
+// reads. Estimates come from recorded recovery history where
+// available, scaled by current cluster size, so the output is a
+// planning aid for maintenance windows rather than a guarantee.
+func simulateCampaign(ctx context.Context, c *kube.Client, targets []campaign.Target, opts campaign.Options, historyFile string) error {
+	entries, err := history.Read(historyFile)
+	if err != nil {
+		return err
+	}
+
+	type estimate struct {
+		target campaign.Target
+		pods   int64
+		dur    time.Duration
+		source string
+	}
+	estimates := make([]estimate, 0, len(targets))
+	for _, t := range targets {
+		cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+		if err != nil {
+			return err
+		}
+		pods := cl.DataNodes()
+		if cl.Spec.Nodes.Master != nil {
+			pods += cl.Spec.Nodes.Master.Replicas
+		}
+		dur, source := estimateCluster(entries, t, pods)
+		estimates = append(estimates, estimate{target: t, pods: pods, dur: dur, source: source})
+	}
+
+	// Assign clusters to workers the way Run does: each goes to the
+	// lane that frees up first.
+	workers := opts.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	lanes := make([]time.Duration, workers)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tPODS\tESTIMATE\tSTART\tFINISH\tBASIS")
+	var total time.Duration
+	for _, e := range estimates {
+		lane := 0
+		for i := range lanes {
+			if lanes[i] < lanes[lane] {
+				lane = i
+			}
+		}
+		start := lanes[lane]
+		finish := start + e.dur
+		lanes[lane] = finish
+		if finish > total {
+			total = finish
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t+%s\t+%s\t%s\n",
+			e.target, e.pods, e.dur.Round(time.Second),
+			start.Round(time.Second), finish.Round(time.Second), e.source)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nestimated total: %s for %d cluster(s), %d at a time\n",
+		total.Round(time.Second), len(targets), workers)
+	return nil
+}
+
+// estimateCluster picks the best available estimate for one cluster:
+// its own recorded cluster recoveries, then its per-pod history scaled
+// to the current size, then the fleet-wide pod average, and finally a
+// fixed fallback.
+func estimateCluster(entries []history.Entry, t campaign.Target, pods int64) (time.Duration, string) {
+	var clusterSum float64
+	var clusterRuns int
+	var podSum, fleetSum float64
+	var podRuns, fleetRuns int
+	for _, e := range entries {
+		if e.Status != "succeeded" {
+			continue
+		}
+		mine := e.Namespace == t.Namespace && e.Cluster == t.Name
+		switch e.Kind {
+		case "cluster":
+			if mine {
+				clusterSum += e.Seconds
+				clusterRuns++
+			}
+		case "pod":
+			fleetSum += e.Seconds
+			fleetRuns++
+			if mine {
+				podSum += e.Seconds
+				podRuns++
+			}
+		}
+	}
+	switch {
+	case clusterRuns > 0:
+		return time.Duration(clusterSum / float64(clusterRuns) * float64(time.Second)),
+			fmt.Sprintf("%d recorded run(s)", clusterRuns)
+	case podRuns > 0:
+		return time.Duration(podSum / float64(podRuns) * float64(pods) * float64(time.Second)),
+			fmt.Sprintf("%d recorded pod restart(s)", podRuns)
+	case fleetRuns > 0:
+		return time.Duration(fleetSum / float64(fleetRuns) * float64(pods) * float64(time.Second)),
+			"fleet-wide pod average"
+	default:
+		return time.Duration(pods) * simulateFallbackPod, "no history, fixed per-pod estimate"
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "snapshot",
+		summary: "export cratedbs, StatefulSets and pods to a directory for offline use",
+		run:     runSnapshot,
+	})
+}
+
+// runSnapshot exports the objects the read-only commands care about to
+// one JSON file per resource. The result feeds -offline, so plans can
+// be reviewed — and the planning logic exercised — without any cluster
+// access.
+func runSnapshot(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: crt snapshot <dir>")
+	}
+	dir := fs.Arg(0)
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	namespace := g.ResolveNamespace(c)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	component := url.Values{"labelSelector": []string{"app.kubernetes.io/component=cratedb"}}
+	exports := []struct {
+		plural string
+		path   string
+		query  url.Values
+	}{
+		{cratedb.Plural, kube.ResourcePath(cratedb.APIVersion, namespace, cratedb.Plural, ""), nil},
+		{"statefulsets", kube.ResourcePath("apps/v1", namespace, "statefulsets", ""), component},
+		{"pods", kube.ResourcePath("v1", namespace, "pods", ""), component},
+	}
+	for _, ex := range exports {
+		items, err := c.List(ctx, ex.path, ex.query)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, ex.plural+".json")
+		if err := os.WriteFile(file, b, 0o644); err != nil {
+			return err
+		}
+		cli.Infof("%d %s written to %s", len(items), ex.plural, file)
+	}
+	return nil
+}
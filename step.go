@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/WalBeh/go-tool-p1/internal/campaign"
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "step",
+		summary: "execute exactly one pending pod restart of a persisted plan",
+		run:     runStep,
+	})
+}
+
+// stepPlan is the state shared between step invocations, persisted in
+// a ConfigMap so workflow engines (Argo Workflows, Temporal, Jenkins)
+// can run the tool as a series of idempotent steps with their own
+// retry semantics: each invocation restarts the next not-done pod and
+// records the outcome; a retried invocation simply picks the same pod
+// up again.
+type stepPlan struct {
+	Created time.Time  `json:"created"`
+	By      string     `json:"by"`
+	Steps   []stepUnit `json:"steps"`
+}
+
+type stepUnit struct {
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster"`
+	Pod       string    `json:"pod"`
+	Status    string    `json:"status"` // pending, done, or failed
+	Error     string    `json:"error,omitempty"`
+	Finished  time.Time `json:"finished"`
+}
+
+func runStep(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("step", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	opts := campaign.DefaultOptions()
+	fs.DurationVar(&opts.PodTimeout, "pod-timeout", opts.PodTimeout, "max wait for the pod to return Ready")
+	fs.DurationVar(&opts.HealthTimeout, "health-timeout", opts.HealthTimeout, "max wait for the cluster to report GREEN")
+	fs.StringVar(&opts.MinHealth, "min-health", opts.MinHealth, "health required before and after the step")
+	plan := fs.String("plan", "", "plan name; state lives in ConfigMap crt-plan-<name>")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+	if *plan == "" {
+		return fmt.Errorf("-plan is required")
+	}
+	if !cratedb.KnownHealth(opts.MinHealth) {
+		return fmt.Errorf("invalid -min-health %q", opts.MinHealth)
+	}
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+
+	path := kube.ResourcePath("v1", c.Namespace(), "configmaps", "crt-plan-"+*plan)
+	p, err := loadPlan(ctx, c, path)
+	if kube.IsNotFound(err) {
+		if fs.NArg() == 0 {
+			return fmt.Errorf("plan %q does not exist; pass [namespace/]cluster targets to create it", *plan)
+		}
+		if p, err = buildPlan(ctx, c, fs.Args(), &g); err != nil {
+			return err
+		}
+		if err := savePlan(ctx, c, path, p); err != nil {
+			return err
+		}
+		cli.Infof("plan %s created: %d pod step(s)", *plan, len(p.Steps))
+	} else if err != nil {
+		return err
+	}
+
+	idx := -1
+	for i, s := range p.Steps {
+		if s.Status != "done" {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		cli.Infof("plan %s: all %d step(s) done", *plan, len(p.Steps))
+		return nil
+	}
+	s := &p.Steps[idx]
+	t := campaign.Target{Namespace: s.Namespace, Name: s.Cluster}
+	cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+	if err != nil {
+		return err
+	}
+	if h := cl.Health(); !cratedb.HealthAtLeast(h, opts.MinHealth) {
+		return fmt.Errorf("step %d/%d: %s health is %s, below %s; retry when recovered",
+			idx+1, len(p.Steps), t, h, opts.MinHealth)
+	}
+
+	cp := campaign.New([]campaign.Target{t}, opts)
+	cp.Operator = cli.Identity(c)
+	cli.Infof("plan %s: step %d/%d: restarting pod %s of %s", *plan, idx+1, len(p.Steps), s.Pod, t)
+	stepErr := cp.RestartPod(ctx, c, t, s.Pod)
+	s.Finished = time.Now()
+	if stepErr != nil {
+		s.Status, s.Error = "failed", stepErr.Error()
+	} else {
+		s.Status, s.Error = "done", ""
+	}
+	if err := savePlan(ctx, c, path, p); err != nil {
+		return err
+	}
+	if stepErr != nil {
+		return fmt.Errorf("step %d/%d: pod %s: %w", idx+1, len(p.Steps), s.Pod, stepErr)
+	}
+	remaining := 0
+	for _, s := range p.Steps {
+		if s.Status != "done" {
+			remaining++
+		}
+	}
+	cli.Infof("plan %s: step %d/%d done, %d remaining", *plan, idx+1, len(p.Steps), remaining)
+	return nil
+}
+
+// buildPlan expands targets into one step per pod, in the same order a
+// campaign would restart them.
+func buildPlan(ctx context.Context, c *kube.Client, targetArgs []string, g *cli.GlobalFlags) (*stepPlan, error) {
+	targets, err := parseTargets(targetArgs, g)
+	if err != nil {
+		return nil, err
+	}
+	p := &stepPlan{Created: time.Now().UTC(), By: cli.Identity(c)}
+	for _, t := range targets {
+		cl, err := cratedb.Get(ctx, c, t.Namespace, t.Name)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := cratedb.Pods(ctx, c, cl)
+		if err != nil {
+			return nil, err
+		}
+		if len(pods) == 0 {
+			return nil, fmt.Errorf("%s: no crate pods found", t)
+		}
+		for _, pod := range cratedb.RestartOrder(pods, t.Name, nil) {
+			p.Steps = append(p.Steps, stepUnit{
+				Namespace: t.Namespace,
+				Cluster:   t.Name,
+				Pod:       unstructured.NestedString(pod, "metadata", "name"),
+				Status:    "pending",
+			})
+		}
+	}
+	return p, nil
+}
+
+func loadPlan(ctx context.Context, c *kube.Client, path string) (*stepPlan, error) {
+	var cm map[string]any
+	if err := c.Get(ctx, path, &cm); err != nil {
+		return nil, err
+	}
+	var p stepPlan
+	if err := json.Unmarshal([]byte(unstructured.NestedString(cm, "data", "plan")), &p); err != nil {
+		return nil, fmt.Errorf("parsing plan state: %w", err)
+	}
+	return &p, nil
+}
+
+func savePlan(ctx context.Context, c *kube.Client, path string, p *stepPlan) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, _, name := kube.SplitResourcePath(path)
+	obj := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": c.Namespace(),
+		},
+		"data": map[string]any{"plan": string(b)},
+	}
+	return c.Apply(ctx, path, obj)
+}
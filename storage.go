@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratedb"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+func init() {
+	register(command{
+		name:    "storage",
+		summary: "show PVC capacity and usage per cluster",
+		run:     runStorage,
+	})
+}
+
+// volKey identifies one PVC in kubelet stats.
+type volKey struct{ namespace, name string }
+
+// volUsage is what the kubelet reports for a volume.
+type volUsage struct{ used, capacity int64 }
+
+func runStorage(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("storage", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	warnPct := fs.Int("warn-usage", 80, "exit non-zero if any volume exceeds this usage percent (0 disables)")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	clusters, err := cratedb.List(ctx, c, g.ResolveNamespace(c))
+	if err != nil {
+		return err
+	}
+
+	usage := map[volKey]volUsage{}
+	nodes := map[string]bool{}
+	for _, cl := range clusters {
+		pods, err := cratedb.Pods(ctx, c, cl)
+		if err != nil {
+			cli.Warnf("%v", err)
+			continue
+		}
+		for _, pod := range pods {
+			if n := unstructured.NestedString(pod, "spec", "nodeName"); n != "" {
+				nodes[n] = true
+			}
+		}
+	}
+	for node := range nodes {
+		if err := kubeletVolumeStats(ctx, c, node, usage); err != nil {
+			cli.Debugf("stats from node %s: %v", node, err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tCLUSTER\tPVC\tCAPACITY\tUSED\tUSAGE")
+	exceeded := false
+	for _, cl := range clusters {
+		q := url.Values{"labelSelector": []string{cl.PodSelector()}}
+		pvcs, err := c.List(ctx, kube.ResourcePath("v1", cl.Namespace, "persistentvolumeclaims", ""), q)
+		if err != nil {
+			cli.Warnf("listing PVCs for %s/%s: %v", cl.Namespace, cl.Name, err)
+			continue
+		}
+		for _, pvc := range pvcs {
+			name := unstructured.NestedString(pvc, "metadata", "name")
+			capStr := unstructured.NestedString(pvc, "status", "capacity", "storage")
+			if capStr == "" {
+				capStr = unstructured.NestedString(pvc, "spec", "resources", "requests", "storage")
+			}
+			usedStr, pctStr := "-", "-"
+			if u, ok := usage[volKey{cl.Namespace, name}]; ok && u.capacity > 0 {
+				pct := float64(u.used) / float64(u.capacity) * 100
+				usedStr = fmtBytes(u.used)
+				pctStr = fmt.Sprintf("%.0f%%", pct)
+				if *warnPct > 0 && pct >= float64(*warnPct) {
+					pctStr += " !"
+					exceeded = true
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				cl.Namespace, cl.Name, name, capStr, usedStr, pctStr)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if exceeded {
+		return fmt.Errorf("one or more volumes above %d%% usage", *warnPct)
+	}
+	return nil
+}
+
+// kubeletVolumeStats fills usage from one node's stats summary,
+// fetched through the apiserver node proxy.
+func kubeletVolumeStats(ctx context.Context, c *kube.Client, node string, usage map[volKey]volUsage) error {
+	var summary struct {
+		Pods []struct {
+			Volume []struct {
+				UsedBytes     int64 `json:"usedBytes"`
+				CapacityBytes int64 `json:"capacityBytes"`
+				PVCRef        *struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+				} `json:"pvcRef"`
+			} `json:"volume"`
+		} `json:"pods"`
+	}
+	path := kube.ResourcePath("v1", "", "nodes", node) + "/proxy/stats/summary"
+	if err := c.Get(ctx, path, &summary); err != nil {
+		return err
+	}
+	for _, pod := range summary.Pods {
+		for _, v := range pod.Volume {
+			if v.PVCRef == nil {
+				continue
+			}
+			usage[volKey{v.PVCRef.Namespace, v.PVCRef.Name}] = volUsage{used: v.UsedBytes, capacity: v.CapacityBytes}
+		}
+	}
+	return nil
+}
+
+func fmtBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for n/div >= unit && exp < 4 {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ci", float64(n)/float64(div), "KMGTP"[exp])
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/cratesql"
+	"github.com/WalBeh/go-tool-p1/internal/kube"
+)
+
+func init() {
+	register(command{
+		name:    "users",
+		summary: "manage CrateDB users: list|create|drop|grant",
+		run:     runUsers,
+	})
+}
+
+func runUsers(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("users", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	password := fs.String("password", "", "password for create (generated when empty)")
+	on := fs.String("on", "", "object for grant, e.g. TABLE doc.mytable (default: cluster-wide)")
+
+	if len(args) < 1 {
+		return fmt.Errorf("usage: crt users list|create|drop|grant [namespace/]cluster [user] [privileges]")
+	}
+	verb := args[0]
+	if err := cli.Parse(fs, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: crt users %s [namespace/]cluster [user]", verb)
+	}
+
+	c, err := g.Client()
+	if err != nil {
+		return err
+	}
+	ns, cluster, found := strings.Cut(fs.Arg(0), "/")
+	if !found {
+		ns, cluster = c.Namespace(), fs.Arg(0)
+	}
+	sql, err := cratesql.Connect(ctx, c, ns, cluster)
+	if err != nil {
+		return err
+	}
+	defer sql.Close()
+
+	switch verb {
+	case "list":
+		return usersList(ctx, sql)
+	case "create":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("usage: crt users create [namespace/]cluster <user>")
+		}
+		return usersCreate(ctx, c, sql, ns, cluster, fs.Arg(1), *password)
+	case "drop":
+		if fs.NArg() < 2 {
+			return fmt.Errorf("usage: crt users drop [namespace/]cluster <user>")
+		}
+		if _, err := sql.Exec(ctx, fmt.Sprintf(`DROP USER "%s"`, fs.Arg(1))); err != nil {
+			return err
+		}
+		cli.Infof("user %s dropped", fs.Arg(1))
+		return nil
+	case "grant":
+		if fs.NArg() < 3 {
+			return fmt.Errorf("usage: crt users grant [namespace/]cluster <user> <privileges>, e.g. DQL,DML")
+		}
+		return usersGrant(ctx, sql, fs.Arg(1), fs.Arg(2), *on)
+	default:
+		return fmt.Errorf("unknown users subcommand %q", verb)
+	}
+}
+
+func usersList(ctx context.Context, sql *cratesql.Client) error {
+	res, err := sql.Exec(ctx, "SELECT name, superuser FROM sys.users ORDER BY name")
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSUPERUSER")
+	for _, row := range res.Rows {
+		fmt.Fprintf(w, "%v\t%v\n", row[0], row[1])
+	}
+	return w.Flush()
+}
+
+func usersCreate(ctx context.Context, c *kube.Client, sql *cratesql.Client, ns, cluster, user, password string) error {
+	generated := false
+	if password == "" {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			return err
+		}
+		generated = true
+	}
+	stmt := fmt.Sprintf(`CREATE USER "%s" WITH (password = ?)`, user)
+	if _, err := sql.Exec(ctx, stmt, password); err != nil {
+		return err
+	}
+	cli.Infof("user %s created", user)
+	if generated {
+		// Persist generated credentials the same way the operator
+		// does, so nothing ends up only in scrollback.
+		if err := storeUserSecret(ctx, c, ns, cluster, user, password); err != nil {
+			cli.Warnf("storing credentials: %v; password is: %s", err, password)
+		} else {
+			cli.Infof("credentials stored in secret %s", cratesql.UserSecretName(cluster, user))
+		}
+	}
+	return nil
+}
+
+func usersGrant(ctx context.Context, sql *cratesql.Client, user, privileges, on string) error {
+	stmt := fmt.Sprintf(`GRANT %s`, strings.ToUpper(privileges))
+	if on != "" {
+		stmt += " ON " + on
+	}
+	stmt += fmt.Sprintf(` TO "%s"`, user)
+	if _, err := sql.Exec(ctx, stmt); err != nil {
+		return err
+	}
+	cli.Infof("granted %s to %s", strings.ToUpper(privileges), user)
+	return nil
+}
+
+func storeUserSecret(ctx context.Context, c *kube.Client, ns, cluster, user, password string) error {
+	secret := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]any{
+			"name":      cratesql.UserSecretName(cluster, user),
+			"namespace": ns,
+			"labels":    map[string]any{"app.kubernetes.io/name": cluster},
+		},
+		"stringData": map[string]any{"username": user, "password": password},
+	}
+	return c.Apply(ctx, kube.ResourcePath("v1", ns, "secrets", cratesql.UserSecretName(cluster, user)), secret)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/WalBeh/go-tool-p1/internal/cli"
+	"github.com/WalBeh/go-tool-p1/internal/unstructured"
+)
+
+// Build metadata, overridden at release time via
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+func init() {
+	register(command{
+		name:    "version",
+		summary: "print build metadata and the target operator API version",
+		run:     runVersion,
+	})
+}
+
+func runVersion(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	var g cli.GlobalFlags
+	g.Register(fs)
+	short := fs.Bool("short", false, "print only the version number")
+	if err := cli.Parse(fs, args); err != nil {
+		return err
+	}
+
+	if *short {
+		fmt.Println(version)
+		return nil
+	}
+
+	rev, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if rev == "" {
+					rev = s.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = s.Value
+				}
+			}
+		}
+	}
+	fmt.Printf("%s %s\n", progName, version)
+	fmt.Printf("  commit:     %s\n", orUnknown(rev))
+	fmt.Printf("  built:      %s\n", orUnknown(date))
+	fmt.Printf("  go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	// Operator API detection is best-effort: version must also work
+	// without a reachable cluster.
+	apiVersions := "unavailable"
+	if c, err := g.Client(); err == nil {
+		var group struct {
+			Versions []struct {
+				Version string `json:"version"`
+			} `json:"versions"`
+			PreferredVersion map[string]any `json:"preferredVersion"`
+		}
+		if err := c.Get(ctx, "/apis/cloud.crate.io", &group); err == nil {
+			apiVersions = ""
+			for i, v := range group.Versions {
+				if i > 0 {
+					apiVersions += ","
+				}
+				apiVersions += v.Version
+			}
+			if pref := unstructured.NestedString(group.PreferredVersion, "version"); pref != "" {
+				apiVersions += " (preferred: " + pref + ")"
+			}
+		}
+	}
+	fmt.Printf("  operator:   cloud.crate.io %s\n", apiVersions)
+	return nil
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}